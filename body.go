@@ -0,0 +1,152 @@
+package httpapi
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// MaxBodySize returns a route option that sets the maximum size in bytes of the request body of the route.
+func MaxBodySize(n int64) RouteOption {
+	return func(r *Route) {
+		r.Settings.MaxBodySize = n
+	}
+}
+
+// DecompressBody returns a route option that decompresses gzip and deflate encoded request bodies of the route.
+func DecompressBody() RouteOption {
+	return func(r *Route) {
+		r.Settings.DecompressBody = true
+	}
+}
+
+// bodyTooLarge returns the error that is returned when a request body is larger than the limit.
+func bodyTooLarge(limit int64) error {
+	return Errorf(http.StatusRequestEntityTooLarge, "request body too large: limit is %d bytes", limit)
+}
+
+// limitBody limits and decompresses the request body as configured by the settings.
+// A error is returned when the request can be rejected before the body is read.
+func (s *Settings) limitBody(r *http.Request) error {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+
+	if s.MaxBodySize > 0 {
+		if r.ContentLength > s.MaxBodySize {
+			return bodyTooLarge(s.MaxBodySize)
+		}
+
+		r.Body = &limitedBody{ReadCloser: r.Body, n: s.MaxBodySize, limit: s.MaxBodySize}
+	}
+
+	if !s.DecompressBody {
+		return nil
+	}
+
+	var body io.ReadCloser
+
+	switch encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))); encoding {
+	case "", "identity":
+		return nil
+	case "gzip", "x-gzip":
+		body = &decodedBody{body: r.Body, encoding: "gzip", open: func(r io.Reader) (io.Reader, error) {
+			return gzip.NewReader(r)
+		}}
+	case "deflate":
+		body = &decodedBody{body: r.Body, encoding: "deflate", open: func(r io.Reader) (io.Reader, error) {
+			return flate.NewReader(r), nil
+		}}
+	default:
+		return Errorf(http.StatusUnsupportedMediaType, "unsupported content encoding %q", encoding)
+	}
+
+	// The decompressed body is limited too, to protect handles from compression bombs.
+	if s.MaxBodySize > 0 {
+		body = &limitedBody{ReadCloser: body, n: s.MaxBodySize, limit: s.MaxBodySize}
+	}
+
+	r.Body = body
+	r.ContentLength = -1
+	r.Header.Del("Content-Encoding")
+	r.Header.Del("Content-Length")
+
+	return nil
+}
+
+// limitedBody is a request body that returns a 413 error when more than limit bytes is read.
+type limitedBody struct {
+	io.ReadCloser
+	n     int64
+	limit int64
+	err   error
+}
+
+// Read reads from the body until the limit is exceeded.
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	// Read one byte more than allowed to know if the limit is exceeded.
+	if int64(len(p)) > b.n+1 {
+		p = p[:b.n+1]
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) <= b.n {
+		b.n -= int64(n)
+		b.err = err
+		return n, err
+	}
+
+	n = int(b.n)
+	b.n = 0
+	b.err = bodyTooLarge(b.limit)
+
+	return n, b.err
+}
+
+// decodedBody is a request body that is decompressed when it's first read.
+type decodedBody struct {
+	body     io.ReadCloser
+	encoding string
+	open     func(io.Reader) (io.Reader, error)
+	r        io.Reader
+	err      error
+}
+
+// Read reads decompressed data from the body. Invalid data is reported with a 400 error.
+func (b *decodedBody) Read(p []byte) (int, error) {
+	if b.r == nil && b.err == nil {
+		b.r, b.err = b.open(b.body)
+		b.err = b.badRequest(b.err)
+	}
+
+	if b.err != nil {
+		return 0, b.err
+	}
+
+	n, err := b.r.Read(p)
+	return n, b.badRequest(err)
+}
+
+// badRequest converts decompression errors to 400 errors, errors with a status code is kept.
+func (b *decodedBody) badRequest(err error) error {
+	if err == nil || err == io.EOF || StatusCode(err) != 0 {
+		return err
+	}
+
+	return Errorf(http.StatusBadRequest, "invalid %s request body: %s", b.encoding, err)
+}
+
+// Close closes the body.
+func (b *decodedBody) Close() error {
+	return b.body.Close()
+}
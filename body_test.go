@@ -0,0 +1,100 @@
+package httpapi
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func gzipData(s string) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(s))
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestMaxBodySize(t *testing.T) {
+	readBody := func(r *http.Request) (interface{}, interface{}) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		return string(body), nil
+	}
+
+	router := NewRouter()
+	router.Settings.MaxBodySize = 4
+	router.Post("/small", readBody)
+	router.Post("/large", readBody, MaxBodySize(16))
+
+	tests := []struct {
+		path   string
+		body   string
+		status int
+		chunk  bool
+	}{
+		{"/small", "abc", http.StatusOK, false},
+		{"/small", "too large", http.StatusRequestEntityTooLarge, false},
+		{"/small", "too large", http.StatusRequestEntityTooLarge, true},
+		{"/large", "not too large", http.StatusOK, false},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", test.path, strings.NewReader(test.body))
+		if test.chunk {
+			r.ContentLength = -1
+		}
+		router.ServeHTTP(w, r)
+
+		if w.Code != test.status {
+			t.Errorf("%s %q: wrong status: want %d, got %d", test.path, test.body, test.status, w.Code)
+		}
+	}
+}
+
+func TestDecompressBody(t *testing.T) {
+	router := NewRouter()
+	router.Settings.DecompressBody = true
+	router.Settings.MaxBodySize = 64
+	router.Post("/", func(r *http.Request) (interface{}, interface{}) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		return string(body), nil
+	})
+
+	tests := []struct {
+		encoding string
+		body     []byte
+		status   int
+		response string
+	}{
+		{"gzip", gzipData("hello"), http.StatusOK, `"hello"`},
+		{"", []byte("plain"), http.StatusOK, `"plain"`},
+		{"gzip", []byte("not gzip"), http.StatusBadRequest, ""},
+		{"gzip", gzipData(strings.Repeat("a", 1024)), http.StatusRequestEntityTooLarge, ""},
+		{"br", []byte("data"), http.StatusUnsupportedMediaType, ""},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/", bytes.NewReader(test.body))
+		r.Header.Set("Content-Encoding", test.encoding)
+		router.ServeHTTP(w, r)
+
+		if w.Code != test.status {
+			t.Errorf("%q: wrong status: want %d, got %d", test.encoding, test.status, w.Code)
+		}
+
+		if test.response != "" && w.Body.String() != test.response {
+			t.Errorf("%q: wrong body: want %s, got %s", test.encoding, test.response, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,568 @@
+// Package clientgen generates a typed Go client for the routes of a httpapi router.
+//
+// The client has a method for each route with a handle that returns data, e.g
+// func(ctx context.Context, ps httpapi.Params) (*User, error). Path params is
+// arguments of the method, a argument of the handle that is decoded from the
+// request body is encoded as JSON and the returned type is decoded from the
+// response. Error responses is returned as a *Error.
+//
+// The generator runs with the router of the service, e.g from a go:generate program:
+//
+//	src, err := clientgen.Generate(api.NewRouter(), clientgen.Options{Package: "apiclient"})
+package clientgen
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/format"
+	"net/http"
+	"path"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/frozzare/go-httpapi"
+	"github.com/frozzare/go-httpapi/internal/gocode"
+	"github.com/julienschmidt/httprouter"
+)
+
+// Options configures the generated client.
+type Options struct {
+	// Package is the package name of the generated code, "client" is used if empty.
+	Package string
+}
+
+var (
+	errorType          = reflect.TypeOf((*error)(nil)).Elem()
+	emptyInterfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+	responseType       = reflect.TypeOf((*httpapi.Response)(nil))
+	pageType           = reflect.TypeOf((*httpapi.Page)(nil))
+)
+
+// rawMessageType is the result type of handles that returns interface{}.
+const rawMessageType = "json.RawMessage"
+
+// argumentTypes contains the handle arguments that is not decoded from the request body.
+var argumentTypes = map[reflect.Type]bool{
+	reflect.TypeOf((*context.Context)(nil)).Elem():     true,
+	reflect.TypeOf((*httpapi.Context)(nil)):            true,
+	reflect.TypeOf((*http.Request)(nil)):               true,
+	reflect.TypeOf((*http.ResponseWriter)(nil)).Elem(): true,
+	reflect.TypeOf(httpapi.Params(nil)):                true,
+	reflect.TypeOf(httprouter.Params(nil)):             true,
+}
+
+// reserved contains names that can't be used for path params in the generated methods.
+var reserved = map[string]bool{
+	"break": true, "case": true, "chan": true, "const": true, "continue": true,
+	"default": true, "defer": true, "else": true, "fallthrough": true, "for": true,
+	"func": true, "go": true, "goto": true, "if": true, "import": true,
+	"interface": true, "map": true, "package": true, "range": true, "return": true,
+	"select": true, "struct": true, "switch": true, "type": true, "var": true,
+	"c": true, "ctx": true, "body": true, "opts": true, "out": true, "err": true,
+	"url": true, "strings": true, "json": true, "http": true,
+}
+
+// method is a method of the generated client.
+type method struct {
+	name   string
+	route  *httpapi.Route
+	params []string
+	url    string
+	body   string
+	result string
+}
+
+// generator contains the state of the generated code.
+type generator struct {
+	imports map[string]string
+	aliases map[string]bool
+	page    bool
+}
+
+// Generate generates the source of a Go client package for the routes of the router.
+// Routes with handles that writes the response themselves, e.g http.HandlerFunc, is skipped.
+func Generate(router *httpapi.Router, opts Options) ([]byte, error) {
+	if opts.Package == "" {
+		opts.Package = "client"
+	}
+
+	g := &generator{
+		imports: map[string]string{
+			"bytes":         "bytes",
+			"context":       "context",
+			"encoding/json": "json",
+			"fmt":           "fmt",
+			"io":            "io",
+			"io/ioutil":     "ioutil",
+			"net/http":      "http",
+			"net/url":       "url",
+		},
+		aliases: make(map[string]bool),
+	}
+
+	for _, alias := range g.imports {
+		g.aliases[alias] = true
+	}
+
+	// The strings package is imported when a route has a catch-all param.
+	g.aliases["strings"] = true
+
+	var methods []*method
+	names := make(map[string]int)
+
+	for _, route := range router.Routes() {
+		m, err := g.method(route)
+		if err != nil {
+			return nil, fmt.Errorf("clientgen: %s %s: %v", route.Method, route.Path, err)
+		}
+
+		if m == nil {
+			continue
+		}
+
+		// Routes with the same name, e.g the same path for different hosts, is numbered.
+		names[m.name]++
+		if n := names[m.name]; n > 1 {
+			m.name = fmt.Sprintf("%s%d", m.name, n)
+		}
+
+		methods = append(methods, m)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by httpapi clientgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", opts.Package)
+
+	gocode.WriteImports(&buf, g.imports)
+
+	buf.WriteString(clientSource)
+
+	if g.page {
+		buf.WriteString(pageSource)
+	}
+
+	for _, m := range methods {
+		writeMethod(&buf, m)
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+// method returns the client method of the route, or nil if the handle don't return data.
+func (g *generator) method(route *httpapi.Route) (*method, error) {
+	t := reflect.TypeOf(route.Handle)
+	if t == nil || t.Kind() != reflect.Func {
+		return nil, nil
+	}
+
+	m := &method{route: route}
+
+	switch {
+	case t.NumOut() == 1 && t.Out(0) == errorType:
+	case t.NumOut() == 2 && (t.Out(1) == errorType || t.Out(1) == emptyInterfaceType):
+		if out := t.Out(0); out == emptyInterfaceType || out == responseType {
+			m.result = rawMessageType
+		} else if out == pageType {
+			// The items of a page is interface{}, so they are decoded by the caller.
+			m.result = "*Page"
+			g.page = true
+		} else {
+			result, err := g.typeString(out)
+			if err != nil {
+				return nil, err
+			}
+			m.result = result
+		}
+	default:
+		return nil, nil
+	}
+
+	for i := 0; i < t.NumIn(); i++ {
+		if argumentTypes[t.In(i)] {
+			continue
+		}
+
+		body, err := g.typeString(t.In(i))
+		if err != nil {
+			return nil, err
+		}
+		m.body = body
+	}
+
+	name := route.Name
+	if name == "" {
+		name = strings.ToLower(route.Method)
+	}
+
+	var url []string
+	literal := ""
+
+	for _, part := range strings.Split(route.Path, "/")[1:] {
+		if part == "" || part[0] != ':' && part[0] != '*' {
+			literal += "/" + part
+			if route.Name == "" {
+				name += "_" + part
+			}
+			continue
+		}
+
+		param := paramName(part[1:])
+		for contains(m.params, param) || g.aliases[param] {
+			param += "_"
+		}
+		m.params = append(m.params, param)
+
+		if route.Name == "" {
+			name += "_by_" + part[1:]
+		}
+
+		if part[0] == '*' {
+			// Catch-all params contains the rest of the path and is not escaped.
+			url = append(url, fmt.Sprintf("%q", literal+"/"), fmt.Sprintf("strings.TrimPrefix(%s, \"/\")", param))
+			g.imports["strings"] = "strings"
+		} else {
+			url = append(url, fmt.Sprintf("%q", literal+"/"), fmt.Sprintf("url.PathEscape(%s)", param))
+		}
+
+		literal = ""
+	}
+
+	if literal != "" || len(url) == 0 {
+		url = append(url, fmt.Sprintf("%q", literal))
+	}
+
+	m.name = gocode.Name(name)
+	m.url = strings.Join(url, " + ")
+
+	return m, nil
+}
+
+// typeString returns the type as Go code and imports the packages of named types.
+func (g *generator) typeString(t reflect.Type) (string, error) {
+	if t == pageType.Elem() {
+		return "", fmt.Errorf("type %s is only supported as the result of a handle", t)
+	}
+
+	if t.Name() != "" {
+		if t.PkgPath() == "" {
+			return t.Name(), nil
+		}
+
+		if t.PkgPath() == "main" || strings.Contains(t.PkgPath(), "/internal/") || strings.HasSuffix(t.PkgPath(), "/internal") {
+			return "", fmt.Errorf("type %s can't be imported from package %s", t, t.PkgPath())
+		}
+
+		if r := []rune(t.Name()); !unicode.IsUpper(r[0]) {
+			return "", fmt.Errorf("type %s is not exported", t)
+		}
+
+		return g.importPath(t.PkgPath()) + "." + t.Name(), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		elem, err := g.typeString(t.Elem())
+		return "*" + elem, err
+	case reflect.Slice:
+		elem, err := g.typeString(t.Elem())
+		return "[]" + elem, err
+	case reflect.Array:
+		elem, err := g.typeString(t.Elem())
+		return fmt.Sprintf("[%d]%s", t.Len(), elem), err
+	case reflect.Map:
+		key, err := g.typeString(t.Key())
+		if err != nil {
+			return "", err
+		}
+		elem, err := g.typeString(t.Elem())
+		return "map[" + key + "]" + elem, err
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return "interface{}", nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported type %s", t)
+}
+
+// importPath imports the package and returns its alias.
+func (g *generator) importPath(pkg string) string {
+	if alias, ok := g.imports[pkg]; ok {
+		return alias
+	}
+
+	base := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, path.Base(pkg))
+
+	if base == "" || unicode.IsDigit([]rune(base)[0]) {
+		base = "pkg" + base
+	}
+
+	alias := base
+	for i := 2; g.aliases[alias]; i++ {
+		alias = fmt.Sprintf("%s%d", base, i)
+	}
+
+	g.imports[pkg] = alias
+	g.aliases[alias] = true
+
+	return alias
+}
+
+// writeMethod writes the client method.
+func writeMethod(buf *bytes.Buffer, m *method) {
+	args := []string{"ctx context.Context"}
+	for _, p := range m.params {
+		args = append(args, p+" string")
+	}
+	if m.body != "" {
+		args = append(args, "body "+m.body)
+	}
+	args = append(args, "opts ...RequestOption")
+
+	body := "nil"
+	if m.body != "" {
+		body = "body"
+	}
+
+	fmt.Fprintf(buf, "// %s sends a %s request to %s.\n", m.name, m.route.Method, m.route.Path)
+
+	if m.result == "" {
+		fmt.Fprintf(buf, "func (c *Client) %s(%s) error {\n", m.name, strings.Join(args, ", "))
+		fmt.Fprintf(buf, "return c.do(ctx, %q, %s, %s, nil, opts)\n", m.route.Method, m.url, body)
+		buf.WriteString("}\n\n")
+		return
+	}
+
+	fmt.Fprintf(buf, "func (c *Client) %s(%s) (%s, error) {\n", m.name, strings.Join(args, ", "), m.result)
+	fmt.Fprintf(buf, "var out %s\n", m.result)
+	fmt.Fprintf(buf, "err := c.do(ctx, %q, %s, %s, &out, opts)\n", m.route.Method, m.url, body)
+	buf.WriteString("return out, err\n")
+	buf.WriteString("}\n\n")
+}
+
+// paramName converts a path param name, e.g "user_id", to a unexported Go name, e.g "userID".
+func paramName(s string) string {
+	r := []rune(gocode.Name(s))
+
+	// Lower case the leading upper case letters, but keep the last one if it starts a word, e.g "URLPath" to "urlPath".
+	n := 0
+	for n < len(r) && unicode.IsUpper(r[n]) {
+		n++
+	}
+	if n > 1 && n < len(r) {
+		n--
+	}
+	for i := 0; i < n; i++ {
+		r[i] = unicode.ToLower(r[i])
+	}
+
+	name := string(r)
+	if reserved[name] {
+		name += "Param"
+	}
+
+	return name
+}
+
+// contains reports whether the list contains the string.
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pageSource is the page type of the generated code, it's only generated when a handle returns a *httpapi.Page.
+const pageSource = `// Page is a page of items of a list response.
+type Page struct {
+	// Items is the JSON array of the items, decode it with json.Unmarshal.
+	Items json.RawMessage ` + "`json:\"items\"`" + `
+
+	// Meta is the metadata of the page.
+	Meta PageMeta ` + "`json:\"meta\"`" + `
+}
+
+// PageMeta is the metadata of a page.
+type PageMeta struct {
+	// Page is the page number, it's zero when cursors is used.
+	Page int ` + "`json:\"page,omitempty\"`" + `
+
+	// Limit is the maximum number of items of the page.
+	Limit int ` + "`json:\"limit\"`" + `
+
+	// Total is the total number of items, or nil if unknown.
+	Total *int ` + "`json:\"total,omitempty\"`" + `
+
+	// NextCursor is the cursor of the next page, there is no next page if it's empty.
+	NextCursor string ` + "`json:\"next_cursor,omitempty\"`" + `
+}
+
+`
+
+// clientSource is the client type and helpers of the generated code.
+const clientSource = `// Client is a client of the API.
+type Client struct {
+	// BaseURL is the URL of the API, e.g "https://api.example.com".
+	BaseURL string
+
+	// HTTPClient sends the requests, http.DefaultClient is used if nil.
+	HTTPClient *http.Client
+
+	// Header contains headers that is added to all requests.
+	Header http.Header
+}
+
+// New creates a new client for the API at the base URL.
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL: baseURL,
+		Header:  make(http.Header),
+	}
+}
+
+// RequestOption modifies a request before it's sent.
+type RequestOption func(*http.Request)
+
+// WithQuery returns a request option that adds the query params to the request.
+func WithQuery(query url.Values) RequestOption {
+	return func(r *http.Request) {
+		q := r.URL.Query()
+		for k, v := range query {
+			q[k] = append(q[k], v...)
+		}
+		r.URL.RawQuery = q.Encode()
+	}
+}
+
+// WithHeader returns a request option that sets a header of the request.
+func WithHeader(key, value string) RequestOption {
+	return func(r *http.Request) {
+		r.Header.Set(key, value)
+	}
+}
+
+// Error is a error response of the API. Error messages, e.g {"error": "not found"},
+// and problem details objects is decoded into the error.
+type Error struct {
+	// Status is the status code of the response.
+	Status int
+
+	// Message is the error message, or the detail or title of a problem details object.
+	Message string
+
+	// Type, Title and Detail is the fields of a problem details object.
+	Type   string
+	Title  string
+	Detail string
+
+	// Body is the response body.
+	Body []byte
+}
+
+// Error returns the error message.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s", e.Status, e.Message)
+}
+
+// StatusCode returns the status code of the response, so the error can be returned from a httpapi handle.
+func (e *Error) StatusCode() int {
+	return e.Status
+}
+
+// newError creates a error from the status code and body of a response.
+func newError(status int, body []byte) *Error {
+	var v struct {
+		Error  string ` + "`json:\"error\"`" + `
+		Type   string ` + "`json:\"type\"`" + `
+		Title  string ` + "`json:\"title\"`" + `
+		Detail string ` + "`json:\"detail\"`" + `
+	}
+
+	json.Unmarshal(body, &v)
+
+	e := &Error{
+		Status:  status,
+		Message: v.Error,
+		Type:    v.Type,
+		Title:   v.Title,
+		Detail:  v.Detail,
+		Body:    body,
+	}
+
+	for _, msg := range []string{v.Detail, v.Title, http.StatusText(status)} {
+		if e.Message == "" {
+			e.Message = msg
+		}
+	}
+
+	return e
+}
+
+// do sends a request with the JSON encoded input and decodes the response into the output.
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}, opts []RequestOption) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.BaseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+
+	for k, v := range c.Header {
+		req.Header[k] = v
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	for _, opt := range opts {
+		opt(req)
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode >= 400 {
+		return newError(res.StatusCode, data)
+	}
+
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(data, out)
+}
+
+`
@@ -0,0 +1,127 @@
+package clientgen
+
+import (
+	"context"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frozzare/go-httpapi"
+)
+
+type User struct {
+	ID      string    `json:"id"`
+	Created time.Time `json:"created"`
+}
+
+type user struct {
+	ID string `json:"id"`
+}
+
+func TestParamName(t *testing.T) {
+	tests := map[string]string{
+		"id":       "id",
+		"user_id":  "userID",
+		"URLPath":  "urlPath",
+		"filepath": "filepath",
+		"type":     "typeParam",
+		"ctx":      "ctxParam",
+	}
+
+	for in, want := range tests {
+		if got := paramName(in); got != want {
+			t.Errorf("paramName(%q): want %q, got %q", in, want, got)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	router := httpapi.NewRouter()
+	router.Get("/users", func(ctx context.Context) ([]*User, error) {
+		return nil, nil
+	})
+	router.Post("/users", func(ctx context.Context, in *User) (*User, error) {
+		return in, nil
+	}, httpapi.Name("createUser"))
+	router.Get("/users/:id", func(ps httpapi.Params) (*User, error) {
+		return nil, nil
+	})
+	router.Delete("/users/:id", func(ps httpapi.Params) error {
+		return nil
+	})
+	router.Get("/users/:id/tags", func(r *http.Request) (interface{}, interface{}) {
+		return nil, nil
+	})
+	router.Get("/files/*filepath", func(w http.ResponseWriter, r *http.Request) {})
+	router.Get("/teams", func(r *http.Request) (*httpapi.Page, error) {
+		return nil, nil
+	})
+
+	src, err := Generate(router, Options{Package: "usersclient"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := parser.ParseFile(token.NewFileSet(), "client.go", src, 0); err != nil {
+		t.Fatalf("generated code don't parse: %v\n%s", err, src)
+	}
+
+	code := string(src)
+
+	for _, want := range []string{
+		"package usersclient",
+		`"github.com/frozzare/go-httpapi/clientgen"`,
+		"func (c *Client) GetUsers(ctx context.Context, opts ...RequestOption) ([]*clientgen.User, error) {",
+		"func (c *Client) CreateUser(ctx context.Context, body *clientgen.User, opts ...RequestOption) (*clientgen.User, error) {",
+		"func (c *Client) GetUsersByID(ctx context.Context, id string, opts ...RequestOption) (*clientgen.User, error) {",
+		`err := c.do(ctx, "GET", "/users/"+url.PathEscape(id), nil, &out, opts)`,
+		"func (c *Client) DeleteUsersByID(ctx context.Context, id string, opts ...RequestOption) error {",
+		"func (c *Client) GetUsersByIDTags(ctx context.Context, id string, opts ...RequestOption) (json.RawMessage, error) {",
+		`"/users/"+url.PathEscape(id)+"/tags"`,
+		"func (e *Error) StatusCode() int",
+		"func (c *Client) GetTeams(ctx context.Context, opts ...RequestOption) (*Page, error) {",
+		"type Page struct {",
+		"Total *int `json:\"total,omitempty\"`",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code don't contain %q:\n%s", want, code)
+		}
+	}
+
+	if strings.Contains(code, "/files/") {
+		t.Errorf("generated code contains a method for a handle without data:\n%s", code)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	router := httpapi.NewRouter()
+	router.Get("/users/:id", func(ps httpapi.Params) (*user, error) {
+		return nil, nil
+	})
+
+	_, err := Generate(router, Options{})
+	if err == nil || !strings.Contains(err.Error(), "GET /users/:id") || !strings.Contains(err.Error(), "not exported") {
+		t.Errorf("wrong error: got %v", err)
+	}
+
+	router = httpapi.NewRouter()
+	router.Get("/events", func() (chan int, error) {
+		return nil, nil
+	})
+
+	if _, err := Generate(router, Options{}); err == nil || !strings.Contains(err.Error(), "unsupported type") {
+		t.Errorf("wrong error: got %v", err)
+	}
+
+	router = httpapi.NewRouter()
+	router.Get("/pages", func() ([]*httpapi.Page, error) {
+		return nil, nil
+	})
+
+	if _, err := Generate(router, Options{}); err == nil || !strings.Contains(err.Error(), "only supported as the result") {
+		t.Errorf("wrong error: got %v", err)
+	}
+}
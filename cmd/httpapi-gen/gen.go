@@ -0,0 +1,416 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/frozzare/go-httpapi/internal/gocode"
+	"github.com/frozzare/go-httpapi/openapi"
+)
+
+// routerPath converts a OpenAPI path template, e.g "/users/{id}", to a router path, e.g "/users/:id".
+func routerPath(tmpl string) string {
+	parts := strings.Split(tmpl, "/")
+
+	for i, part := range parts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			parts[i] = ":" + part[1:len(part)-1]
+		}
+	}
+
+	return strings.Join(parts, "/")
+}
+
+// generator generates Go code from a OpenAPI document.
+type generator struct {
+	doc      *openapi.Document
+	types    bytes.Buffer
+	declared map[string]bool
+	structs  map[string]bool
+	imports  map[string]string
+}
+
+// operation is a operation that is generated.
+type operation struct {
+	name         string
+	summary      string
+	method       string
+	path         string
+	params       []*param
+	body         string
+	bodyRequired bool
+	result       string
+	status       int
+}
+
+// param is a path, query or header param of a operation.
+type param struct {
+	name  string
+	in    string
+	field string
+	typ   string
+}
+
+// generate generates Go code with types, a server interface and a register function from the document.
+func generate(doc *openapi.Document, pkg string) ([]byte, error) {
+	g := &generator{
+		doc:      doc,
+		declared: make(map[string]bool),
+		structs:  make(map[string]bool),
+		imports: map[string]string{
+			"context":                        "context",
+			"github.com/frozzare/go-httpapi": "",
+		},
+	}
+
+	names := make([]string, 0, len(doc.Components.Schemas))
+	for name := range doc.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Declare all component types first so references to them is known to be structs.
+	for _, name := range names {
+		if s := doc.Components.Schemas[name]; s.Ref == "" && len(s.Properties) > 0 {
+			g.structs[gocode.Name(name)] = true
+		}
+	}
+
+	for _, name := range names {
+		g.declare(gocode.Name(name), doc.Components.Schemas[name])
+	}
+
+	var ops []*operation
+	for _, route := range doc.Routes() {
+		ops = append(ops, g.operation(route))
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by httpapi-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+
+	gocode.WriteImports(&buf, g.imports)
+
+	buf.Write(g.types.Bytes())
+
+	buf.WriteString("// Server contains the operations of the API.\ntype Server interface {\n")
+	for _, op := range ops {
+		if op.summary != "" {
+			fmt.Fprintf(&buf, "// %s %s\n", op.name, op.summary)
+		}
+
+		if op.result == "" {
+			fmt.Fprintf(&buf, "%s(ctx context.Context, params *%sParams) error\n", op.name, op.name)
+		} else {
+			fmt.Fprintf(&buf, "%s(ctx context.Context, params *%sParams) (%s, error)\n", op.name, op.name, op.result)
+		}
+	}
+	buf.WriteString("}\n\n")
+
+	buf.WriteString("// Register registers the operations of the server on the router.\n")
+	buf.WriteString("func Register(router *httpapi.Router, s Server) {\n")
+	for i, op := range ops {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		g.writeHandle(&buf, op)
+	}
+	buf.WriteString("}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %v", err)
+	}
+
+	return src, nil
+}
+
+// operation declares the params type of the route and returns the operation.
+func (g *generator) operation(route *openapi.Route) *operation {
+	op := &operation{
+		name:    gocode.Name(route.Operation.OperationID),
+		summary: route.Operation.Summary,
+		method:  route.Method,
+		path:    routerPath(route.Path),
+		status:  200,
+	}
+
+	if route.Operation.OperationID == "" {
+		words := []string{strings.ToLower(route.Method)}
+		for _, part := range strings.Split(route.Path, "/") {
+			if strings.HasPrefix(part, "{") {
+				part = "by " + strings.Trim(part, "{}")
+			}
+			words = append(words, part)
+		}
+		op.name = gocode.Name(strings.Join(words, " "))
+	}
+
+	var fields bytes.Buffer
+
+	for _, p := range g.doc.Parameters(route) {
+		typ := g.typeExpr(op.name+gocode.Name(p.Name), p.Schema)
+
+		switch p.In {
+		case "path":
+			switch typ {
+			case "int32", "int64":
+				typ = "int64"
+			case "bool", "httpapi.UUID":
+			default:
+				typ = "string"
+			}
+		case "header":
+			typ = "string"
+		case "query":
+		default:
+			continue
+		}
+
+		prm := &param{name: p.Name, in: p.In, field: gocode.Name(p.Name), typ: typ}
+		op.params = append(op.params, prm)
+
+		tag := "-"
+		if p.In == "query" {
+			tag = p.Name
+		}
+
+		fmt.Fprintf(&fields, "%s %s `query:%q`\n", prm.field, prm.typ, tag)
+	}
+
+	if rb := g.doc.RequestBody(route.Operation.RequestBody); rb != nil {
+		if mt := jsonMediaType(rb.Content); mt != nil {
+			op.body = g.typeExpr(op.name+"Body", mt.Schema)
+			op.bodyRequired = rb.Required
+			fmt.Fprintf(&fields, "Body %s `query:\"-\"`\n", op.body)
+		}
+	}
+
+	codes := make([]string, 0, len(route.Operation.Responses))
+	for code := range route.Operation.Responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		if !strings.HasPrefix(code, "2") {
+			continue
+		}
+
+		if n, err := strconv.Atoi(code); err == nil {
+			op.status = n
+		}
+
+		if res := g.doc.Response(route.Operation.Responses[code]); res != nil {
+			if mt := jsonMediaType(res.Content); mt != nil {
+				op.result = g.typeExpr(op.name+"Response", mt.Schema)
+				if g.structs[op.result] {
+					op.result = "*" + op.result
+				}
+			}
+		}
+		break
+	}
+
+	fmt.Fprintf(&g.types, "// %sParams contains the params of %s.\ntype %sParams struct {\n", op.name, op.name, op.name)
+	g.types.Write(fields.Bytes())
+	g.types.WriteString("}\n\n")
+
+	return op
+}
+
+// writeHandle writes the registration of the operation.
+func (g *generator) writeHandle(buf *bytes.Buffer, op *operation) {
+	fmt.Fprintf(buf, "router.Handle(%q, %q, func(c *httpapi.Context) (interface{}, error) {\n", op.method, op.path)
+	fmt.Fprintf(buf, "var params %sParams\n", op.name)
+
+	converted := false
+	query := false
+	for _, p := range op.params {
+		if p.in == "path" && p.typ != "string" {
+			converted = true
+		}
+		if p.in == "query" {
+			query = true
+		}
+	}
+
+	if converted {
+		buf.WriteString("var err error\n")
+	}
+
+	if query {
+		buf.WriteString("if err := c.BindQuery(&params); err != nil {\nreturn nil, err\n}\n")
+	}
+
+	for _, p := range op.params {
+		switch {
+		case p.in == "header":
+			fmt.Fprintf(buf, "params.%s = c.Header(%q)\n", p.field, p.name)
+		case p.in == "path" && p.typ == "string":
+			fmt.Fprintf(buf, "params.%s = c.Param(%q)\n", p.field, p.name)
+		case p.in == "path":
+			accessor := map[string]string{"int64": "Int64", "bool": "Bool", "httpapi.UUID": "UUID"}[p.typ]
+			fmt.Fprintf(buf, "if params.%s, err = c.Params.%s(%q); err != nil {\nreturn nil, err\n}\n", p.field, accessor, p.name)
+		}
+	}
+
+	if op.body != "" {
+		bind := "if err := c.Bind(&params.Body); err != nil {\nreturn nil, err\n}\n"
+		if !op.bodyRequired {
+			bind = "if c.Request.ContentLength != 0 {\n" + bind + "}\n"
+		}
+		buf.WriteString(bind)
+	}
+
+	switch {
+	case op.result == "":
+		fmt.Fprintf(buf, "if err := s.%s(c, &params); err != nil {\nreturn nil, err\n}\n", op.name)
+		fmt.Fprintf(buf, "return c.Respond(%d, nil), nil\n", op.status)
+	default:
+		fmt.Fprintf(buf, "res, err := s.%s(c, &params)\nif err != nil {\nreturn nil, err\n}\n", op.name)
+		if op.status == 200 {
+			buf.WriteString("return res, nil\n")
+		} else {
+			fmt.Fprintf(buf, "return c.Respond(%d, res), nil\n", op.status)
+		}
+	}
+
+	buf.WriteString("})\n")
+}
+
+// jsonMediaType returns the JSON media type of the content.
+func jsonMediaType(content map[string]*openapi.MediaType) *openapi.MediaType {
+	types := make([]string, 0, len(content))
+	for typ := range content {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+
+	for _, typ := range types {
+		if typ == "application/json" || strings.HasSuffix(typ, "+json") {
+			return content[typ]
+		}
+	}
+
+	return nil
+}
+
+// typeExpr returns the Go type of the schema, object schemas with properties
+// is declared as a struct type with the hint as name.
+func (g *generator) typeExpr(hint string, s *openapi.Schema) string {
+	if s == nil {
+		return "interface{}"
+	}
+
+	if s.Ref != "" {
+		return gocode.Name(path.Base(s.Ref))
+	}
+
+	if len(s.AllOf) == 1 {
+		return g.typeExpr(hint, s.AllOf[0])
+	}
+
+	switch s.Type {
+	case "string":
+		switch s.Format {
+		case "date-time":
+			g.imports["time"] = "time"
+			return "time.Time"
+		case "uuid":
+			return "httpapi.UUID"
+		}
+		return "string"
+	case "integer":
+		if s.Format == "int32" {
+			return "int32"
+		}
+		return "int64"
+	case "number":
+		if s.Format == "float" {
+			return "float32"
+		}
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		return "[]" + g.typeExpr(hint+"Item", s.Items)
+	case "object", "":
+		if len(s.Properties) > 0 {
+			g.declare(hint, s)
+			return hint
+		}
+
+		if s.AdditionalProperties != nil {
+			return "map[string]" + g.typeExpr(hint+"Value", s.AdditionalProperties)
+		}
+
+		if s.Type == "object" {
+			return "map[string]interface{}"
+		}
+	}
+
+	return "interface{}"
+}
+
+// declare declares a named type for the schema.
+func (g *generator) declare(name string, s *openapi.Schema) {
+	if g.declared[name] {
+		return
+	}
+	g.declared[name] = true
+
+	comment := fmt.Sprintf("// %s is the %s schema.\n", name, name)
+	if s.Description != "" {
+		comment = "// " + name + " " + strings.Replace(strings.TrimSpace(s.Description), "\n", "\n// ", -1) + "\n"
+	}
+
+	if s.Ref != "" || len(s.Properties) == 0 {
+		typ := g.typeExpr(name+"Value", s)
+		fmt.Fprintf(&g.types, "%stype %s %s\n\n", comment, name, typ)
+		return
+	}
+
+	g.structs[name] = true
+
+	required := make(map[string]bool)
+	for _, r := range s.Required {
+		required[r] = true
+	}
+
+	props := make([]string, 0, len(s.Properties))
+	for prop := range s.Properties {
+		props = append(props, prop)
+	}
+	sort.Strings(props)
+
+	// Fields is written to a own buffer since nested types is declared while the fields are generated.
+	var fields bytes.Buffer
+	for _, prop := range props {
+		ps := s.Properties[prop]
+		typ := g.typeExpr(name+gocode.Name(prop), ps)
+
+		if ps.Nullable && !strings.HasPrefix(typ, "[]") && !strings.HasPrefix(typ, "map[") && typ != "interface{}" {
+			typ = "*" + typ
+		}
+
+		tag := prop
+		if !required[prop] {
+			tag += ",omitempty"
+		}
+
+		if ps.Description != "" {
+			fmt.Fprintf(&fields, "// %s %s\n", gocode.Name(prop), strings.Replace(strings.TrimSpace(ps.Description), "\n", "\n// ", -1))
+		}
+		fmt.Fprintf(&fields, "%s %s `json:%q`\n", gocode.Name(prop), typ, tag)
+	}
+
+	fmt.Fprintf(&g.types, "%stype %s struct {\n", comment, name)
+	g.types.Write(fields.Bytes())
+	g.types.WriteString("}\n\n")
+}
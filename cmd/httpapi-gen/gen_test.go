@@ -0,0 +1,90 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/frozzare/go-httpapi/openapi"
+)
+
+const testDocument = `{
+	"openapi": "3.0.3",
+	"info": {"title": "Users", "version": "1.0.0"},
+	"paths": {
+		"/users": {
+			"get": {
+				"operationId": "listUsers",
+				"parameters": [
+					{"name": "limit", "in": "query", "schema": {"type": "integer"}},
+					{"name": "X-Request-ID", "in": "header", "schema": {"type": "string"}}
+				],
+				"responses": {"200": {"description": "ok", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/User"}}}}}}
+			},
+			"post": {
+				"operationId": "createUser",
+				"requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/User"}}}},
+				"responses": {"201": {"description": "created", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/User"}}}}}
+			}
+		},
+		"/users/{id}": {
+			"parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}}],
+			"delete": {"responses": {"204": {"description": "deleted"}}}
+		}
+	},
+	"components": {
+		"schemas": {
+			"User": {
+				"type": "object",
+				"required": ["name"],
+				"properties": {
+					"name": {"type": "string"},
+					"created_at": {"type": "string", "format": "date-time"},
+					"address": {"type": "object", "properties": {"street": {"type": "string"}}}
+				}
+			}
+		}
+	}
+}`
+
+func TestGenerate(t *testing.T) {
+	doc, err := openapi.Load([]byte(testDocument))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := generate(doc, "api")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := parser.ParseFile(token.NewFileSet(), "api.go", src, 0); err != nil {
+		t.Fatalf("generated code don't parse: %v", err)
+	}
+
+	code := string(src)
+	for _, want := range []string{
+		"package api",
+		"type User struct {",
+		"Address   UserAddress `json:\"address,omitempty\"`",
+		"CreatedAt time.Time   `json:\"created_at,omitempty\"`",
+		"Name      string      `json:\"name\"`",
+		"type UserAddress struct {",
+		"Limit      int64  `query:\"limit\"`",
+		"XRequestID string `query:\"-\"`",
+		"Body User `query:\"-\"`",
+		"ListUsers(ctx context.Context, params *ListUsersParams) ([]User, error)",
+		"CreateUser(ctx context.Context, params *CreateUserParams) (*User, error)",
+		"DeleteUsersByID(ctx context.Context, params *DeleteUsersByIDParams) error",
+		"func Register(router *httpapi.Router, s Server) {",
+		`router.Handle("DELETE", "/users/:id", func(c *httpapi.Context) (interface{}, error) {`,
+		`if params.ID, err = c.Params.Int64("id"); err != nil {`,
+		"return c.Respond(201, res), nil",
+		"return c.Respond(204, nil), nil",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code don't contain %q:\n%s", want, code)
+		}
+	}
+}
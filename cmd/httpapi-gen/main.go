@@ -0,0 +1,49 @@
+// Command httpapi-gen generates Go code for a httpapi router from a OpenAPI 3 document.
+//
+// The generated code contains the types of the schemas, a params type for each
+// operation, a Server interface with a method for each operation and a Register
+// function that registers the operations of a Server on a *httpapi.Router.
+//
+//	httpapi-gen -in openapi.json -out api.gen.go -package api
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/frozzare/go-httpapi/openapi"
+)
+
+func main() {
+	in := flag.String("in", "openapi.json", "the OpenAPI document to generate code from")
+	out := flag.String("out", "", "the file to write the generated code to, stdout is used if empty")
+	pkg := flag.String("package", "api", "the package name of the generated code")
+	flag.Parse()
+
+	if err := run(*in, *out, *pkg); err != nil {
+		fmt.Fprintln(os.Stderr, "httpapi-gen:", err)
+		os.Exit(1)
+	}
+}
+
+// run generates code from the document in the input file and writes it to the output file.
+func run(in, out, pkg string) error {
+	doc, err := openapi.LoadFile(in)
+	if err != nil {
+		return err
+	}
+
+	src, err := generate(doc, pkg)
+	if err != nil {
+		return err
+	}
+
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+
+	return ioutil.WriteFile(out, src, 0644)
+}
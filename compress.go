@@ -0,0 +1,347 @@
+package httpapi
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CompressWriter is a writer that compresses data. Writers are pooled and reset between responses.
+type CompressWriter interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// CompressOptions contains the options of the compress middleware.
+type CompressOptions struct {
+	// Level is the compression level used by gzip and deflate.
+	// Defaults to gzip.DefaultCompression.
+	Level int
+
+	// MinSize is the minimum size in bytes of a response before it's compressed.
+	// Defaults to 1024. Flushed responses are always compressed.
+	MinSize int
+
+	// ContentTypes contains the content types that is compressed. Types ending
+	// with a '/' matches all sub types, e.g "text/". Defaults to JSON, XML,
+	// JavaScript and text content types.
+	ContentTypes []string
+
+	// Encoders contains additional encoders keyed by content encoding, e.g "br" or "zstd".
+	// Encoders can also replace the default gzip and deflate encoders.
+	Encoders map[string]func() CompressWriter
+
+	// Preference contains the content encodings in the order that is preferred
+	// when the client accepts multiple with the same quality. Defaults to
+	// "br", "zstd", "gzip" and "deflate".
+	Preference []string
+}
+
+// defaultCompressTypes is the content types that is compressed by default.
+var defaultCompressTypes = []string{
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"application/vnd.api+json",
+	"application/problem+json",
+	"image/svg+xml",
+	"text/",
+}
+
+// compressor negotiates content encodings and pools compress writers.
+type compressor struct {
+	opts  CompressOptions
+	pools map[string]*sync.Pool
+}
+
+// Compress returns a middleware that compresses responses with the content encoding
+// that best matches the Accept-Encoding header of the request. Gzip and deflate is
+// supported by default, other encoders like brotli or zstd can be added with options.
+func Compress(opts CompressOptions) func(http.Handler) http.Handler {
+	if opts.Level == 0 {
+		opts.Level = gzip.DefaultCompression
+	}
+
+	if opts.MinSize <= 0 {
+		opts.MinSize = 1024
+	}
+
+	if len(opts.ContentTypes) == 0 {
+		opts.ContentTypes = defaultCompressTypes
+	}
+
+	if len(opts.Preference) == 0 {
+		opts.Preference = []string{"br", "zstd", "gzip", "deflate"}
+	}
+
+	encoders := map[string]func() CompressWriter{
+		"gzip": func() CompressWriter {
+			w, _ := gzip.NewWriterLevel(nil, opts.Level)
+			return w
+		},
+		"deflate": func() CompressWriter {
+			w, _ := flate.NewWriter(nil, opts.Level)
+			return w
+		},
+	}
+
+	for k, v := range opts.Encoders {
+		encoders[k] = v
+	}
+
+	c := &compressor{
+		opts:  opts,
+		pools: make(map[string]*sync.Pool),
+	}
+
+	for k, v := range encoders {
+		c.pools[k] = &sync.Pool{New: func(fn func() CompressWriter) func() interface{} {
+			return func() interface{} { return fn() }
+		}(v)}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cw := &compressResponseWriter{
+				ResponseWriter: w,
+				compressor:     c,
+				encoding:       c.negotiate(r.Header.Get("Accept-Encoding")),
+				head:           r.Method == "HEAD",
+			}
+			defer cw.Close()
+
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiate returns the content encoding that best matches the accept encoding header, or a empty string for identity.
+func (c *compressor) negotiate(accept string) string {
+	if accept == "" {
+		return ""
+	}
+
+	qs := make(map[string]float64)
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(params[0]))
+
+		q := 1.0
+		for _, p := range params[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				if f, err := strconv.ParseFloat(p[2:], 64); err == nil {
+					q = f
+				}
+			}
+		}
+
+		qs[name] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, name := range c.opts.Preference {
+		if c.pools[name] == nil {
+			continue
+		}
+
+		q, ok := qs[name]
+		if !ok {
+			q, ok = qs["*"]
+		}
+
+		if ok && q > bestQ {
+			best, bestQ = name, q
+		}
+	}
+
+	return best
+}
+
+// compressible reports whether the content type should be compressed.
+func (c *compressor) compressible(contentType string) bool {
+	typ, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, t := range c.opts.ContentTypes {
+		if typ == t || strings.HasSuffix(t, "/") && strings.HasPrefix(typ, t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// compressResponseWriter compresses the response if it's compressible and large enough.
+// Writes are buffered until the minimum size is reached, the response is flushed or closed.
+type compressResponseWriter struct {
+	http.ResponseWriter
+	compressor *compressor
+	encoding   string
+	head       bool
+	status     int
+	buf        []byte
+	decided    bool
+	writer     CompressWriter
+}
+
+// WriteHeader stores the status code until it's decided if the response should be compressed.
+func (w *compressResponseWriter) WriteHeader(status int) {
+	if w.status != 0 {
+		return
+	}
+
+	w.status = status
+
+	// Responses without body is never compressed.
+	if status < 200 || status == http.StatusNoContent || status == http.StatusNotModified || w.head {
+		w.decide(false)
+	}
+}
+
+// Write writes compressed data if the response is compressed.
+func (w *compressResponseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.decided {
+		if w.writer != nil {
+			return w.writer.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.compressor.opts.MinSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// decide decides if the response should be compressed and writes the header and buffered data.
+func (w *compressResponseWriter) decide(large bool) error {
+	if w.decided {
+		return nil
+	}
+	w.decided = true
+
+	h := w.Header()
+	eligible := h.Get("Content-Encoding") == "" && w.compressor.compressible(h.Get("Content-Type"))
+
+	// Partial content is not compressed since the range offsets is of the uncompressed body.
+	if w.status == http.StatusPartialContent || h.Get("Content-Range") != "" {
+		eligible = false
+	}
+
+	if eligible {
+		addVary(h, "Accept-Encoding")
+	}
+
+	if eligible && large && w.encoding != "" {
+		h.Set("Content-Encoding", w.encoding)
+		h.Del("Content-Length")
+
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+
+		w.writer = w.compressor.pools[w.encoding].Get().(CompressWriter)
+		w.writer.Reset(w.ResponseWriter)
+	}
+
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	buf := w.buf
+	w.buf = nil
+
+	var err error
+	if w.writer != nil {
+		_, err = w.writer.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+
+	return err
+}
+
+// Flush compresses and flushes the buffered data, flushed responses are compressed regardless of size.
+func (w *compressResponseWriter) Flush() {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	w.decide(true)
+
+	if w.writer != nil {
+		w.writer.Flush()
+	}
+
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close writes the buffered data and closes the compress writer.
+func (w *compressResponseWriter) Close() error {
+	if w.status == 0 && len(w.buf) == 0 && !w.decided {
+		// Nothing was written by the handler.
+		return nil
+	}
+
+	err := w.decide(false)
+
+	if w.writer != nil {
+		if cerr := w.writer.Close(); err == nil {
+			err = cerr
+		}
+		w.writer.Reset(nil)
+		w.compressor.pools[w.encoding].Put(w.writer)
+		w.writer = nil
+	}
+
+	return err
+}
+
+// Hijack implements the http.Hijacker interface if the underlying response writer does.
+func (w *compressResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+
+	return nil, nil, errors.New("httpapi: response writer don't implement http.Hijacker")
+}
+
+// addVary adds a value to the Vary header if it's not already present.
+func addVary(h http.Header, value string) {
+	for _, v := range h["Vary"] {
+		for _, s := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(s), value) {
+				return
+			}
+		}
+	}
+
+	h.Add("Vary", value)
+}
@@ -0,0 +1,166 @@
+package httpapi
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompress(t *testing.T) {
+	large := strings.Repeat("a", 2048)
+
+	router := NewRouter()
+	router.Use(Compress(CompressOptions{}))
+	router.Get("/large", func() (interface{}, interface{}) {
+		return large, nil
+	})
+	router.Get("/small", func() (interface{}, interface{}) {
+		return "small", nil
+	})
+	router.Get("/image", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte(large))
+	})
+
+	tests := []struct {
+		path     string
+		accept   string
+		encoding string
+		vary     bool
+	}{
+		{"/large", "gzip, deflate", "gzip", true},
+		{"/large", "deflate, gzip;q=0.5", "deflate", true},
+		{"/large", "gzip;q=0, *", "deflate", true},
+		{"/large", "", "", true},
+		{"/small", "gzip", "", true},
+		{"/image", "gzip", "", false},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", test.path, nil)
+		r.Header.Set("Accept-Encoding", test.accept)
+		router.ServeHTTP(w, r)
+
+		if v := w.Header().Get("Content-Encoding"); v != test.encoding {
+			t.Errorf("%s %q: wrong content encoding: want %q, got %q", test.path, test.accept, test.encoding, v)
+		}
+
+		if v := w.Header().Get("Vary") == "Accept-Encoding"; v != test.vary {
+			t.Errorf("%s %q: wrong vary: want %v, got %v", test.path, test.accept, test.vary, v)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/large", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	router.ServeHTTP(w, r)
+
+	gr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := ioutil.ReadAll(gr)
+	if want := `"` + large + `"`; string(body) != want {
+		t.Errorf("wrong body: got %d bytes", len(body))
+	}
+}
+
+func TestCompressRange(t *testing.T) {
+	content := strings.Repeat("abcdefghij", 120)
+
+	router := NewRouter()
+	router.Use(Compress(CompressOptions{MinSize: 10}))
+	router.Get("/file.txt", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "file.txt", time.Time{}, strings.NewReader(content))
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/file.txt", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	r.Header.Set("Range", "bytes=0-99")
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusPartialContent {
+		t.Fatalf("wrong status: want %d, got %d", http.StatusPartialContent, w.Code)
+	}
+
+	if v := w.Header().Get("Content-Encoding"); v != "" {
+		t.Errorf("partial content was compressed: %s", v)
+	}
+
+	if w.Body.String() != content[:100] {
+		t.Errorf("wrong body: got %q", w.Body.String())
+	}
+}
+
+func TestCompressStreaming(t *testing.T) {
+	router := NewRouter()
+	router.Use(Compress(CompressOptions{}))
+	router.Get("/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("first"))
+		w.(http.Flusher).Flush()
+		w.Write([]byte("second"))
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/stream", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	router.ServeHTTP(w, r)
+
+	if !w.Flushed {
+		t.Error("response was not flushed")
+	}
+
+	if v := w.Header().Get("Content-Encoding"); v != "gzip" {
+		t.Fatalf("wrong content encoding: want gzip, got %q", v)
+	}
+
+	gr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, _ := ioutil.ReadAll(gr)
+	if string(body) != "firstsecond" {
+		t.Errorf("wrong body: want %q, got %q", "firstsecond", body)
+	}
+}
+
+func TestCompressETag(t *testing.T) {
+	router := NewRouter()
+	router.Settings.ETag = StrongETag
+	router.Use(Compress(CompressOptions{MinSize: 1}))
+	router.Get("/users/1", func() (interface{}, interface{}) {
+		return "fredrik", nil
+	})
+	router.Put("/users/1", func() (interface{}, interface{}) {
+		return "updated", nil
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/users/1", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	router.ServeHTTP(w, r)
+
+	etag := w.Header().Get("ETag")
+	if !strings.HasPrefix(etag, `W/"`) {
+		t.Fatalf("compressed etag is not weak: got %q", etag)
+	}
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("PUT", "/users/1", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	r.Header.Set("If-Match", strings.TrimPrefix(etag, "W/"))
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("wrong status: want %d, got %d", http.StatusOK, w.Code)
+	}
+}
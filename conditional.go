@@ -0,0 +1,157 @@
+package httpapi
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ETagMode controls how the default response handle computes ETags.
+type ETagMode int
+
+const (
+	// NoETag disables computed ETags.
+	NoETag ETagMode = iota
+
+	// StrongETag computes strong ETags from the encoded data.
+	StrongETag
+
+	// WeakETag computes weak ETags from the encoded data.
+	WeakETag
+)
+
+// computeETag computes a ETag from the body.
+func computeETag(body []byte, mode ETagMode) string {
+	sum := sha1.Sum(body)
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+
+	if mode == WeakETag {
+		return "W/" + etag
+	}
+
+	return etag
+}
+
+// quoteETag quotes a ETag if it's not already quoted.
+func quoteETag(etag string) string {
+	if strings.HasSuffix(etag, `"`) {
+		return etag
+	}
+
+	if strings.HasPrefix(etag, "W/") {
+		return `W/"` + etag[2:] + `"`
+	}
+
+	return `"` + etag + `"`
+}
+
+// matchETag reports whether the etag matches any of the etags in the header value.
+// Weak comparison ignores the weak indicator, strong comparison never matches weak etags.
+func matchETag(header, etag string, weak bool) bool {
+	if etag == "" {
+		return false
+	}
+
+	for _, v := range strings.Split(header, ",") {
+		v = strings.TrimSpace(v)
+
+		if v == "*" {
+			return true
+		}
+
+		if weak {
+			if strings.TrimPrefix(v, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		} else if !strings.HasPrefix(v, "W/") && !strings.HasPrefix(etag, "W/") && v == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// notModified reports whether a GET or HEAD request can be responded with 304 Not Modified.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		return false
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return matchETag(inm, etag, true)
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(ims)
+		return err == nil && !lastModified.Truncate(time.Second).After(t)
+	}
+
+	return false
+}
+
+// CheckPreconditions evaluates the If-Match and If-Unmodified-Since headers of a
+// request against the current ETag and modification time of a resource. Use a
+// empty ETag and zero time when the resource don't exist.
+// The returned error responds with 412 Precondition Failed when returned from a handle.
+func CheckPreconditions(r *http.Request, etag string, lastModified time.Time) error {
+	if etag != "" {
+		etag = quoteETag(etag)
+	}
+
+	if im := r.Header.Get("If-Match"); im != "" {
+		if !matchETag(im, etag, false) {
+			return NewError(http.StatusPreconditionFailed, "")
+		}
+		return nil
+	}
+
+	if ius := r.Header.Get("If-Unmodified-Since"); ius != "" {
+		t, err := http.ParseTime(ius)
+		if err == nil && (lastModified.IsZero() || lastModified.Truncate(time.Second).After(t)) {
+			return NewError(http.StatusPreconditionFailed, "")
+		}
+	}
+
+	return nil
+}
+
+// Preconditions returns a route option that sets the function that returns the current
+// ETag and Last-Modified of the resource of unsafe requests, see Settings.Preconditions.
+func Preconditions(fn func(r *http.Request) (etag string, lastModified time.Time, err error)) RouteOption {
+	return func(r *Route) {
+		r.Settings.Preconditions = fn
+	}
+}
+
+// preconditions returns a handler that evaluates If-Match and If-Unmodified-Since
+// headers on unsafe methods before calling next, when the settings has a Preconditions
+// function that returns the current ETag and Last-Modified of the resource.
+func preconditions(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case "GET", "HEAD", "OPTIONS", "TRACE":
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		s := settingsFromRequest(req)
+		if s.Preconditions == nil || req.Header.Get("If-Match") == "" && req.Header.Get("If-Unmodified-Since") == "" {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		etag, lastModified, err := s.Preconditions(req)
+		if err == nil {
+			err = CheckPreconditions(req, etag, lastModified)
+		}
+
+		if err != nil {
+			s.handleError(w, req, err)
+			return
+		}
+
+		next.ServeHTTP(w, req)
+	})
+}
@@ -0,0 +1,165 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestETag(t *testing.T) {
+	router := NewRouter()
+	router.Settings.ETag = StrongETag
+	router.Get("/users/1", func() (interface{}, interface{}) {
+		return map[string]string{"name": "fredrik"}, nil
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/users/1", nil)
+	router.ServeHTTP(w, r)
+
+	etag := w.Header().Get("ETag")
+	if etag == "" || etag[0] != '"' {
+		t.Fatalf("missing strong etag: got %q", etag)
+	}
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("GET", "/users/1", nil)
+	r.Header.Set("If-None-Match", `"other", W/`+etag)
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("wrong status: want %d, got %d", http.StatusNotModified, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("GET", "/users/1", nil)
+	r.Header.Set("If-None-Match", `"other"`)
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("wrong status: want %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestResponseValidators(t *testing.T) {
+	modified := time.Date(2020, 3, 4, 10, 0, 0, 0, time.UTC)
+
+	router := NewRouter()
+	router.Get("/users/1", func() (interface{}, interface{}) {
+		return &Response{
+			Data:         "fredrik",
+			ETag:         "v1",
+			LastModified: modified,
+			Header:       http.Header{"Cache-Control": []string{"max-age=60"}},
+		}, nil
+	})
+	router.Post("/users", func() (interface{}, interface{}) {
+		return &Response{Status: http.StatusCreated, Data: "created"}, nil
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/users/1", nil)
+	router.ServeHTTP(w, r)
+
+	if w.Header().Get("ETag") != `"v1"` || w.Header().Get("Last-Modified") != modified.Format(http.TimeFormat) || w.Header().Get("Cache-Control") != "max-age=60" {
+		t.Errorf("wrong headers: got %v", w.Header())
+	}
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("GET", "/users/1", nil)
+	r.Header.Set("If-Modified-Since", modified.Add(time.Minute).Format(http.TimeFormat))
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusNotModified {
+		t.Errorf("wrong status: want %d, got %d", http.StatusNotModified, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("POST", "/users", nil)
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusCreated || w.Body.String() != `"created"` {
+		t.Errorf("wrong response: got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestPreconditions(t *testing.T) {
+	var name = "fredrik"
+	var updates, gets int
+
+	router := NewRouter()
+	router.Settings.ETag = StrongETag
+	router.Get("/users/:id", func() (interface{}, interface{}) {
+		gets++
+		return name, nil
+	})
+	router.Put("/users/:id", func(r *http.Request) (interface{}, interface{}) {
+		updates++
+		name = "gopher"
+		return name, nil
+	}, Preconditions(func(r *http.Request) (string, time.Time, error) {
+		return computeETag([]byte(`"`+name+`"`), StrongETag), time.Time{}, nil
+	}))
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/users/1", nil)
+	router.ServeHTTP(w, r)
+	etag := w.Header().Get("ETag")
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("PUT", "/users/1", strings.NewReader(`"gopher"`))
+	r.Header.Set("If-Match", etag)
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK || updates != 1 {
+		t.Fatalf("wrong status: want %d, got %d", http.StatusOK, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("PUT", "/users/1", strings.NewReader(`"gopher"`))
+	r.Header.Set("If-Match", etag)
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusPreconditionFailed || updates != 1 {
+		t.Errorf("wrong status: want %d, got %d", http.StatusPreconditionFailed, w.Code)
+	}
+
+	if gets != 1 {
+		t.Errorf("GET handle was called %d times", gets)
+	}
+}
+
+func TestCheckPreconditions(t *testing.T) {
+	modified := time.Date(2020, 3, 4, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		header string
+		value  string
+		etag   string
+		fail   bool
+	}{
+		{"If-Match", `"v1"`, "v1", false},
+		{"If-Match", `"v2"`, "v1", true},
+		{"If-Match", `W/"v1"`, "v1", true},
+		{"If-Match", "*", "v1", false},
+		{"If-Match", "*", "", true},
+		{"If-Unmodified-Since", modified.Format(http.TimeFormat), "", false},
+		{"If-Unmodified-Since", modified.Add(-time.Minute).Format(http.TimeFormat), "", true},
+	}
+
+	for _, test := range tests {
+		r, _ := http.NewRequest("PUT", "/", nil)
+		r.Header.Set(test.header, test.value)
+
+		err := CheckPreconditions(r, test.etag, modified)
+		if (err != nil) != test.fail {
+			t.Errorf("wrong result for %s %s: got %v", test.header, test.value, err)
+		}
+
+		if err != nil && StatusCode(err) != http.StatusPreconditionFailed {
+			t.Errorf("wrong status: got %d", StatusCode(err))
+		}
+	}
+}
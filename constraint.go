@@ -0,0 +1,173 @@
+package httpapi
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// uuidRegexp matches a UUID in its canonical form.
+var uuidRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Constraint reports whether a path param value is valid.
+type Constraint func(value string) bool
+
+// ParseConstraint parses a constraint spec. The following specs are supported:
+//
+//	int             a integer, e.g "-1" or "42"
+//	uuid            a UUID, e.g "123e4567-e89b-12d3-a456-426614174000"
+//	enum(a,b,c)     one of the given values
+//	len(n)          exactly n characters
+//	len(min,max)    between min and max characters
+//
+// Any other spec is used as a regular expression that must match the whole value.
+func ParseConstraint(spec string) (Constraint, error) {
+	switch {
+	case spec == "int":
+		return func(v string) bool {
+			_, err := strconv.ParseInt(v, 10, 64)
+			return err == nil
+		}, nil
+	case spec == "uuid":
+		return uuidRegexp.MatchString, nil
+	case strings.HasPrefix(spec, "enum(") && strings.HasSuffix(spec, ")"):
+		values := strings.Split(spec[5:len(spec)-1], ",")
+		return func(v string) bool {
+			for _, value := range values {
+				if v == value {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case strings.HasPrefix(spec, "len(") && strings.HasSuffix(spec, ")"):
+		args := strings.Split(spec[4:len(spec)-1], ",")
+		if len(args) > 2 {
+			return nil, fmt.Errorf("invalid constraint %q", spec)
+		}
+
+		min, err := strconv.Atoi(strings.TrimSpace(args[0]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid constraint %q: %v", spec, err)
+		}
+
+		max := min
+		if len(args) == 2 {
+			if max, err = strconv.Atoi(strings.TrimSpace(args[1])); err != nil {
+				return nil, fmt.Errorf("invalid constraint %q: %v", spec, err)
+			}
+		}
+
+		return func(v string) bool {
+			n := utf8.RuneCountInString(v)
+			return n >= min && n <= max
+		}, nil
+	default:
+		re, err := regexp.Compile("^(?:" + spec + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid constraint %q: %v", spec, err)
+		}
+		return re.MatchString, nil
+	}
+}
+
+// paramConstraint is a constraint of a named path param.
+type paramConstraint struct {
+	param      string
+	spec       string
+	constraint Constraint
+}
+
+// Where returns a route option that adds a constraint to the given path param.
+// See ParseConstraint for supported specs. It panics if the spec is invalid.
+func Where(param, spec string) RouteOption {
+	c, err := ParseConstraint(spec)
+	if err != nil {
+		panic(err)
+	}
+
+	return WhereFunc(param, spec, c)
+}
+
+// WhereFunc returns a route option that adds a custom constraint to the given path param.
+// The description is used in the error message when the value is invalid.
+func WhereFunc(param, description string, c Constraint) RouteOption {
+	return func(r *Route) {
+		r.constraints = append(r.constraints, paramConstraint{param, description, c})
+	}
+}
+
+// parsePathConstraints removes constraints from path params, e.g "/users/:id<int>",
+// and returns the path without them and the parsed constraints.
+func parsePathConstraints(path string) (string, []paramConstraint) {
+	if !strings.Contains(path, "<") {
+		return path, nil
+	}
+
+	var constraints []paramConstraint
+
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if len(s) == 0 || s[0] != ':' && s[0] != '*' {
+			continue
+		}
+
+		start := strings.IndexByte(s, '<')
+		if start < 0 || s[len(s)-1] != '>' {
+			continue
+		}
+
+		param, spec := s[1:start], s[start+1:len(s)-1]
+
+		c, err := ParseConstraint(spec)
+		if err != nil {
+			panic(err.Error() + " in path '" + path + "'")
+		}
+
+		constraints = append(constraints, paramConstraint{param, spec, c})
+		segments[i] = s[:start]
+	}
+
+	return strings.Join(segments, "/"), constraints
+}
+
+// check checks the route constraints against the request params and
+// returns a error describing the first invalid param, if any.
+func (r *Route) check(req *http.Request) error {
+	ps := ParamsFromContext(req.Context())
+
+	for _, c := range r.constraints {
+		v := ps.ByName(c.param)
+		if !c.constraint(v) {
+			return Errorf(http.StatusBadRequest, "invalid value %q for param %q: must match %s", v, c.param, c.spec)
+		}
+	}
+
+	return nil
+}
+
+// matcher returns a matcher that matches requests with valid params, or nil if the route don't have any constraints.
+func (r *Route) matcher() matcher {
+	if len(r.constraints) == 0 {
+		return nil
+	}
+
+	return func(req *http.Request) bool {
+		return r.check(req) == nil
+	}
+}
+
+// constraintHandler returns a handler that responds with 400 Bad Request when the params are invalid.
+func (r *Route) constraintHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := r.check(req); err != nil {
+			settingsFromRequest(req).handleError(w, req, err)
+			return
+		}
+
+		next.ServeHTTP(w, req)
+	})
+}
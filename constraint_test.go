@@ -0,0 +1,111 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseConstraint(t *testing.T) {
+	tests := []struct {
+		spec  string
+		value string
+		match bool
+	}{
+		{"int", "42", true},
+		{"int", "-1", true},
+		{"int", "abc", false},
+		{"uuid", "123e4567-e89b-12d3-a456-426614174000", true},
+		{"uuid", "123e4567", false},
+		{"enum(open,closed)", "open", true},
+		{"enum(open,closed)", "pending", false},
+		{"len(3)", "abc", true},
+		{"len(3)", "abcd", false},
+		{"len(2,4)", "abcd", true},
+		{"len(2,4)", "a", false},
+		{"[a-z]+", "abc", true},
+		{"[a-z]+", "abc1", false},
+	}
+
+	for _, test := range tests {
+		c, err := ParseConstraint(test.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if c(test.value) != test.match {
+			t.Errorf("wrong match for %s with %q: want %v", test.spec, test.value, test.match)
+		}
+	}
+
+	if _, err := ParseConstraint("len(a)"); err == nil {
+		t.Error("expected error for invalid spec")
+	}
+}
+
+func TestConstraints(t *testing.T) {
+	router := NewRouter()
+	router.Get("/users/:id<int>", func(ps Params) (interface{}, interface{}) {
+		return "int", nil
+	})
+	router.Get("/users/:id<uuid>", func(ps Params) (interface{}, interface{}) {
+		return "uuid", nil
+	})
+	router.Get("/issues/:status", func(ps Params) (interface{}, interface{}) {
+		return ps.ByName("status"), nil
+	}, Where("status", "enum(open,closed)"))
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/users/42", http.StatusOK, `"int"`},
+		{"/users/123e4567-e89b-12d3-a456-426614174000", http.StatusOK, `"uuid"`},
+		{"/users/fredrik", http.StatusNotFound, ""},
+		{"/issues/open", http.StatusOK, `"open"`},
+		{"/issues/pending", http.StatusNotFound, ""},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", test.path, nil)
+		router.ServeHTTP(w, r)
+
+		if w.Code != test.status {
+			t.Errorf("wrong status for %s: want %d, got %d", test.path, test.status, w.Code)
+		}
+
+		if test.body != "" && w.Body.String() != test.body {
+			t.Errorf("wrong body for %s: want %s, got %s", test.path, test.body, w.Body.String())
+		}
+	}
+}
+
+func TestConstraintsBadRequest(t *testing.T) {
+	var called bool
+
+	router := NewRouter()
+	router.Settings.BadRequestOnConstraint = true
+	router.Get("/users/:id<int>", func(ps Params) (interface{}, interface{}) {
+		called = true
+		return nil, nil
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/users/fredrik", nil)
+	router.ServeHTTP(w, r)
+
+	if called {
+		t.Error("handle called with invalid param")
+	}
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("wrong status: want %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	want := `{"error":"invalid value \"fredrik\" for param \"id\": must match int"}`
+	if w.Body.String() != want {
+		t.Errorf("wrong body: want %s, got %s", want, w.Body.String())
+	}
+}
@@ -0,0 +1,121 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"mime"
+	"net/http"
+)
+
+// Context contains the request, params and response writer of a request.
+// It's a context.Context itself, canceled when the request context is canceled,
+// so it can be passed to functions that respects cancellation.
+type Context struct {
+	context.Context
+
+	// Request is the HTTP request.
+	Request *http.Request
+
+	// Params is the URL params of the request.
+	Params Params
+
+	// Writer is the response writer of the request.
+	Writer http.ResponseWriter
+}
+
+// NewContext creates a new context for the request. It's useful when testing
+// handles that takes a context without a router.
+func NewContext(w http.ResponseWriter, r *http.Request, ps Params) *Context {
+	return &Context{
+		Context: r.Context(),
+		Request: r,
+		Params:  ps,
+		Writer:  w,
+	}
+}
+
+// Param returns the value of the URL param with the given name.
+func (c *Context) Param(name string) string {
+	return c.Params.ByName(name)
+}
+
+// Query returns the first value of the query param with the given name.
+func (c *Context) Query(name string) string {
+	return c.Request.URL.Query().Get(name)
+}
+
+// Header returns the first value of the request header with the given name.
+func (c *Context) Header(name string) string {
+	return c.Request.Header.Get(name)
+}
+
+// Set stores a value in the context, the value is available for the rest of the request with Value.
+func (c *Context) Set(key, value interface{}) {
+	c.Context = context.WithValue(c.Context, key, value)
+	c.Request = c.Request.WithContext(c.Context)
+}
+
+// Bind decodes the JSON request body into v. The error responds with
+// 415 Unsupported Media Type or 400 Bad Request when returned from a handle.
+func (c *Context) Bind(v interface{}) error {
+	if ct := c.Request.Header.Get("Content-Type"); ct != "" {
+		if mt, _, err := mime.ParseMediaType(ct); err != nil || mt != "application/json" {
+			return NewError(http.StatusUnsupportedMediaType, "content type must be application/json")
+		}
+	}
+
+	if c.Request.Body == nil {
+		return NewError(http.StatusBadRequest, "missing request body")
+	}
+
+	body, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		if StatusCode(err) != 0 {
+			return err
+		}
+		return NewError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return NewError(http.StatusBadRequest, err.Error())
+	}
+
+	return nil
+}
+
+// BindQuery decodes the query params of the request into v, see DecodeQuery.
+func (c *Context) BindQuery(v interface{}) error {
+	return DecodeQuery(c.Request.URL.Query(), v)
+}
+
+// SetHeader sets a response header.
+func (c *Context) SetHeader(key, value string) {
+	c.Writer.Header().Set(key, value)
+}
+
+// Respond returns a response with the status code and data, that can be returned from the handle.
+func (c *Context) Respond(status int, data interface{}) *Response {
+	return &Response{Status: status, Data: data}
+}
+
+// Created returns a 201 Created response with the data and a Location header.
+func (c *Context) Created(location string, data interface{}) *Response {
+	return &Response{
+		Status: http.StatusCreated,
+		Header: http.Header{"Location": []string{location}},
+		Data:   data,
+	}
+}
+
+// contextHandle returns a handle that calls the function with a new context
+// and responds with the response handle of the router.
+func (r *Router) contextHandle(fn func(*Context) (interface{}, error)) Handle {
+	respond := r.ResponseHandle
+
+	return func(w http.ResponseWriter, req *http.Request, ps Params) {
+		respond(func(req *http.Request, ps Params) (interface{}, interface{}) {
+			return fn(NewContext(w, req, ps))
+		})(w, req, ps)
+	}
+}
@@ -0,0 +1,84 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestContextHandle(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+	}
+
+	router := NewRouter()
+	router.Get("/users/:id", func(ctx context.Context, ps Params) (interface{}, error) {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return ps.ByName("id"), nil
+	})
+	router.Post("/users", func(c *Context) (interface{}, error) {
+		var u user
+		if err := c.Bind(&u); err != nil {
+			return nil, err
+		}
+
+		c.SetHeader("X-Source", c.Query("source"))
+		return c.Created("/users/"+u.Name, u), nil
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/users/1", nil)
+	router.ServeHTTP(w, r)
+
+	if w.Body.String() != `"1"` {
+		t.Errorf("wrong body: want %s, got %s", `"1"`, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("POST", "/users?source=test", strings.NewReader(`{"name":"fredrik"}`))
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("wrong status: want %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	if v := w.Header().Get("Location"); v != "/users/fredrik" {
+		t.Errorf("wrong location: got %q", v)
+	}
+
+	if v := w.Header().Get("X-Source"); v != "test" {
+		t.Errorf("wrong header: got %q", v)
+	}
+
+	if want := `{"name":"fredrik"}`; w.Body.String() != want {
+		t.Errorf("wrong body: want %s, got %s", want, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("POST", "/users", strings.NewReader(`{"name":`))
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("wrong status: want %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestNewContext(t *testing.T) {
+	type key struct{}
+
+	r, _ := http.NewRequest("GET", "/users/1?expand=true", nil)
+	c := NewContext(httptest.NewRecorder(), r, Params{{Key: "id", Value: "1"}})
+	c.Set(key{}, "value")
+
+	if c.Param("id") != "1" || c.Query("expand") != "true" {
+		t.Errorf("wrong param or query: got %q %q", c.Param("id"), c.Query("expand"))
+	}
+
+	if c.Value(key{}) != "value" || c.Request.Context().Value(key{}) != "value" {
+		t.Error("value is not stored in the context")
+	}
+}
@@ -0,0 +1,186 @@
+package httpapi
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// registry contains the state that is shared between a router and its groups.
+type registry struct {
+	dispatchers map[string]*dispatcher
+	routes      []*Route
+	errors      []error
+	notFound    []*notFoundHandler
+	strict      bool
+}
+
+// notFoundHandler is a handler that is called when no route matches a request to the path prefix.
+type notFoundHandler struct {
+	prefix   string
+	matchers []matcher
+	vary     []string
+	handler  http.Handler
+}
+
+// newRegistry creates a new registry.
+func newRegistry() *registry {
+	return &registry{
+		dispatchers: make(map[string]*dispatcher),
+	}
+}
+
+// matcher reports whether a request matches a route.
+type matcher func(r *http.Request) bool
+
+// candidate is a handler that is used when a request matches all matchers.
+// Vary contains the request headers that the matchers reads.
+type candidate struct {
+	matchers []matcher
+	vary     []string
+	handler  http.Handler
+}
+
+// match reports whether the request matches all matchers of the candidate.
+func (c *candidate) match(r *http.Request) bool {
+	for _, m := range c.matchers {
+		if !m(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// dispatcher dispatches requests for a method and path to the
+// first candidate that matches the request. Candidates with matchers
+// are tried before the candidate without any matchers.
+type dispatcher struct {
+	router     *httprouter.Router
+	candidates []*candidate
+}
+
+// add adds a new candidate to the dispatcher.
+func (d *dispatcher) add(path string, matchers []matcher, vary []string, handler http.Handler) {
+	c := &candidate{
+		matchers: append([]matcher(nil), matchers...),
+		vary:     vary,
+		handler:  handler,
+	}
+
+	if len(matchers) > 0 {
+		// Insert before the candidate without matchers if it exists.
+		n := len(d.candidates)
+		if n > 0 && len(d.candidates[n-1].matchers) == 0 {
+			d.candidates = append(d.candidates[:n-1], c, d.candidates[n-1])
+			return
+		}
+	} else {
+		for _, c := range d.candidates {
+			if len(c.matchers) == 0 {
+				panic("a handle is already registered for path '" + path + "'")
+			}
+		}
+	}
+
+	d.candidates = append(d.candidates, c)
+}
+
+// ServeHTTP dispatches the request to the first matching candidate. The response varies
+// on the headers of all candidates, also when no candidate matches the request.
+func (d *dispatcher) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	for _, c := range d.candidates {
+		for _, h := range c.vary {
+			addVary(w.Header(), h)
+		}
+	}
+
+	for _, c := range d.candidates {
+		if c.match(r) {
+			c.handler.ServeHTTP(w, r)
+			return
+		}
+	}
+
+	if d.router.NotFound != nil {
+		d.router.NotFound.ServeHTTP(w, r)
+	} else {
+		http.NotFound(w, r)
+	}
+}
+
+// handle registers the handler for the method and path, the path should already
+// be joined with the router path. The handler will only be used for requests
+// that matches the router's matchers and the given matchers.
+func (r *Router) handle(method, path string, handler http.Handler, matchers ...matcher) {
+	key := method + " " + path
+
+	d, ok := r.registry.dispatchers[key]
+	if !ok {
+		d = &dispatcher{router: r.router}
+		r.router.Handler(method, path, d)
+		r.registry.dispatchers[key] = d
+	}
+
+	d.add(path, append(append([]matcher(nil), r.matchers...), matchers...), r.vary, handler)
+}
+
+// addNotFound adds a not found handler for the path prefix. The first time a handler
+// is added the not found handler of the httprouter is replaced and used as fallback.
+func (reg *registry) addNotFound(router *httprouter.Router, prefix string, matchers []matcher, vary []string, handler http.Handler) {
+	if reg.notFound == nil {
+		fallback := router.NotFound
+		router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reg.serveNotFound(w, r, fallback)
+		})
+	}
+
+	for _, nf := range reg.notFound {
+		if nf.prefix == prefix && len(nf.matchers) == 0 && len(matchers) == 0 {
+			nf.handler = handler
+			return
+		}
+	}
+
+	reg.notFound = append(reg.notFound, &notFoundHandler{
+		prefix:   prefix,
+		matchers: append([]matcher(nil), matchers...),
+		vary:     vary,
+		handler:  handler,
+	})
+}
+
+// serveNotFound calls the not found handler with the longest prefix that matches the request.
+func (reg *registry) serveNotFound(w http.ResponseWriter, r *http.Request, fallback http.Handler) {
+	var best *notFoundHandler
+
+	for _, nf := range reg.notFound {
+		if nf.prefix != "" && r.URL.Path != nf.prefix && !strings.HasPrefix(r.URL.Path, nf.prefix+"/") {
+			continue
+		}
+
+		for _, h := range nf.vary {
+			addVary(w.Header(), h)
+		}
+
+		c := candidate{matchers: nf.matchers}
+		if !c.match(r) {
+			continue
+		}
+
+		// Handlers with matchers wins over handlers without for the same prefix.
+		if best == nil || len(nf.prefix) > len(best.prefix) || len(nf.prefix) == len(best.prefix) && len(nf.matchers) > len(best.matchers) {
+			best = nf
+		}
+	}
+
+	switch {
+	case best != nil:
+		best.handler.ServeHTTP(w, r)
+	case fallback != nil:
+		fallback.ServeHTTP(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
@@ -0,0 +1,95 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Error represents a error with a HTTP status code.
+// The default error handle responds with the status code of the error.
+type Error struct {
+	Status  int
+	Message string
+}
+
+// NewError creates a new error with the given status code and message.
+// If the message is empty the status text will be used.
+func NewError(status int, message string) *Error {
+	if message == "" {
+		message = http.StatusText(status)
+	}
+
+	return &Error{
+		Status:  status,
+		Message: message,
+	}
+}
+
+// Errorf creates a new error with the given status code and a formatted message.
+func Errorf(status int, format string, args ...interface{}) *Error {
+	return NewError(status, fmt.Sprintf(format, args...))
+}
+
+// Error returns the error message.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// StatusCode returns the HTTP status code of the error.
+func (e *Error) StatusCode() int {
+	return e.Status
+}
+
+// StatusCode returns the HTTP status code of a error if it has one, otherwise zero.
+func StatusCode(err error) int {
+	if e, ok := err.(interface {
+		StatusCode() int
+	}); ok {
+		return e.StatusCode()
+	}
+
+	return 0
+}
+
+// DefaultErrorHandle is the default error handle.
+// Errors with a status code will be responded with that status code.
+// Errors that implements json.Marshaler is responded with their JSON,
+// with the content type from a ContentType() string method if they have one.
+func DefaultErrorHandle(w http.ResponseWriter, r *http.Request, err error) {
+	status := StatusCode(err)
+
+	if m, ok := err.(json.Marshaler); ok {
+		typ := "application/json"
+		if ct, ok := err.(interface {
+			ContentType() string
+		}); ok {
+			typ = ct.ContentType()
+		}
+
+		js, jerr := m.MarshalJSON()
+		if jerr == nil {
+			w.Header().Set("Content-Type", typ)
+			if status != 0 {
+				w.WriteHeader(status)
+			}
+			w.Write(js)
+			return
+		}
+	}
+
+	msg := err.Error()
+
+	if len(msg) > 0 && (msg[0] == '{' && msg[len(msg)-1] == '}' || msg[0] == '[' && msg[len(msg)-1] == ']') {
+		w.Header().Set("Content-Type", "application/json")
+		if status != 0 {
+			w.WriteHeader(status)
+		}
+		fmt.Fprint(w, msg)
+		return
+	}
+
+	writeJSON(w, status, map[string]interface{}{
+		"error": msg,
+	})
+}
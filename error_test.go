@@ -0,0 +1,35 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDefaultErrorHandle(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		body   string
+	}{
+		{errors.New("fail"), http.StatusOK, `{"error":"fail"}`},
+		{errors.New(`{"message":"fail"}`), http.StatusOK, `{"message":"fail"}`},
+		{NewError(http.StatusNotFound, ""), http.StatusNotFound, `{"error":"Not Found"}`},
+		{Errorf(http.StatusBadRequest, "invalid %s", "id"), http.StatusBadRequest, `{"error":"invalid id"}`},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/", nil)
+		DefaultErrorHandle(w, r, test.err)
+
+		if w.Code != test.status {
+			t.Errorf("wrong status for %v: want %d, got %d", test.err, test.status, w.Code)
+		}
+
+		if w.Body.String() != test.body {
+			t.Errorf("wrong body for %v: want %s, got %s", test.err, test.body, w.Body.String())
+		}
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"log"
 	"net/http"
 
 	"github.com/frozzare/go-httpapi"
@@ -31,6 +32,8 @@ func main() {
 		}, nil
 	})
 
-	http.Handle("/", router)
-	http.ListenAndServe(":3000", nil)
+	server := httpapi.NewServer(":3000", router)
+	if err := server.ListenAndServe(); err != nil {
+		log.Fatal(err)
+	}
 }
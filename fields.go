@@ -0,0 +1,186 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// fieldSet contains the selected fields keyed by path, the top level path is empty.
+type fieldSet map[string]map[string]bool
+
+// parseFields parses "fields=a,b" and "fields[path]=a,b" query values.
+// Nested paths are separated by dots, e.g "fields[user.address]=city".
+func parseFields(values url.Values) fieldSet {
+	fs := fieldSet{}
+
+	for key, vals := range values {
+		var path string
+
+		switch {
+		case key == "fields":
+		case strings.HasPrefix(key, "fields[") && strings.HasSuffix(key, "]"):
+			path = key[7 : len(key)-1]
+		default:
+			continue
+		}
+
+		for _, v := range vals {
+			for _, field := range strings.Split(v, ",") {
+				if field = strings.TrimSpace(field); field == "" {
+					continue
+				}
+
+				if fs[path] == nil {
+					fs[path] = make(map[string]bool)
+				}
+
+				fs[path][field] = true
+			}
+		}
+	}
+
+	return fs
+}
+
+// keep reports whether the field should be kept in the object at the given path.
+func (fs fieldSet) keep(path, field string) bool {
+	fields, ok := fs[path]
+	if !ok || fields[field] {
+		return true
+	}
+
+	// Keep fields that have nested fields selected.
+	_, ok = fs[joinFieldPath(path, field)]
+	return ok
+}
+
+// joinFieldPath joins a path and a field with a dot.
+func joinFieldPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+
+	return path + "." + field
+}
+
+// SelectFields returns the JSON representation of the value with only the fields
+// selected by the "fields" query values, e.g "fields=id,name&fields[user]=id".
+// Objects in arrays are pruned with the same fields. The value is returned as is
+// if no fields are selected.
+func SelectFields(v interface{}, values url.Values) (interface{}, error) {
+	fs := parseFields(values)
+	if len(fs) == 0 {
+		return v, nil
+	}
+
+	js, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	js, err = fs.prune(js, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return json.RawMessage(js), nil
+}
+
+// prune removes the fields that are not selected from the JSON value at the given path.
+func (fs fieldSet) prune(js json.RawMessage, path string) (json.RawMessage, error) {
+	js = bytes.TrimSpace(js)
+	if len(js) == 0 {
+		return js, nil
+	}
+
+	switch js[0] {
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(js, &items); err != nil {
+			return nil, err
+		}
+
+		for i, item := range items {
+			pruned, err := fs.prune(item, path)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = pruned
+		}
+
+		return json.Marshal(items)
+	case '{':
+		dec := json.NewDecoder(bytes.NewReader(js))
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+
+		var buf bytes.Buffer
+		buf.WriteByte('{')
+
+		for dec.More() {
+			t, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ := t.(string)
+
+			var value json.RawMessage
+			if err := dec.Decode(&value); err != nil {
+				return nil, err
+			}
+
+			if !fs.keep(path, key) {
+				continue
+			}
+
+			value, err = fs.prune(value, joinFieldPath(path, key))
+			if err != nil {
+				return nil, err
+			}
+
+			if buf.Len() > 1 {
+				buf.WriteByte(',')
+			}
+
+			k, _ := json.Marshal(key)
+			buf.Write(k)
+			buf.WriteByte(':')
+			buf.Write(value)
+		}
+
+		buf.WriteByte('}')
+
+		return buf.Bytes(), nil
+	}
+
+	return js, nil
+}
+
+// selectFields selects the fields of the data if sparse fields are enabled and the response is JSON.
+// The fields of a page are selected on its items.
+func (s *Settings) selectFields(r *http.Request, data interface{}) (interface{}, error) {
+	if !s.SparseFields {
+		return data, nil
+	}
+
+	if typ, _ := s.encoder(r.Header.Get("Accept")); typ != "application/json" && !strings.HasSuffix(typ, "+json") {
+		return data, nil
+	}
+
+	if p, ok := data.(*Page); ok {
+		items, err := SelectFields(p.Items, r.URL.Query())
+		if err != nil {
+			return nil, err
+		}
+
+		c := *p
+		c.Items = items
+		return &c, nil
+	}
+
+	return SelectFields(data, r.URL.Query())
+}
@@ -0,0 +1,79 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+type fieldsUser struct {
+	ID      int               `json:"id"`
+	Name    string            `json:"name"`
+	Email   string            `json:"email"`
+	Address map[string]string `json:"address"`
+}
+
+type fieldsIssue struct {
+	ID     int        `json:"id"`
+	Title  string     `json:"title"`
+	Status string     `json:"status"`
+	User   fieldsUser `json:"user"`
+}
+
+func TestSelectFields(t *testing.T) {
+	issue := fieldsIssue{
+		ID:     1,
+		Title:  "Bug",
+		Status: "open",
+		User: fieldsUser{
+			ID:      2,
+			Name:    "fredrik",
+			Email:   "fredrik@example.com",
+			Address: map[string]string{"city": "Stockholm", "street": "Gatan"},
+		},
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", `{"id":1,"title":"Bug","status":"open","user":{"id":2,"name":"fredrik","email":"fredrik@example.com","address":{"city":"Stockholm","street":"Gatan"}}}`},
+		{"fields=title,id", `{"id":1,"title":"Bug"}`},
+		{"fields=id&fields[user]=name", `{"id":1,"user":{"name":"fredrik"}}`},
+		{"fields[user]=id&fields[user.address]=city", `{"id":1,"title":"Bug","status":"open","user":{"id":2,"address":{"city":"Stockholm"}}}`},
+	}
+
+	for _, test := range tests {
+		values, _ := url.ParseQuery(test.query)
+
+		v, err := SelectFields(issue, values)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		js, _ := json.Marshal(v)
+		if string(js) != test.want {
+			t.Errorf("wrong fields for %q: want %s, got %s", test.query, test.want, js)
+		}
+	}
+}
+
+func TestSparseFields(t *testing.T) {
+	router := NewRouter()
+	router.Settings.SparseFields = true
+	router.Get("/issues", func(r *http.Request) (interface{}, interface{}) {
+		q, _ := ParseListQuery(r, ListOptions{})
+		return NewPage(q, []fieldsIssue{{ID: 1, Title: "Bug"}, {ID: 2, Title: "Feature"}}), nil
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/issues?fields=id", nil)
+	router.ServeHTTP(w, r)
+
+	want := `{"items":[{"id":1},{"id":2}],"meta":{"page":1,"limit":20}}`
+	if w.Body.String() != want {
+		t.Errorf("wrong body: want %s, got %s", want, w.Body.String())
+	}
+}
@@ -0,0 +1,238 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Health statuses.
+const (
+	// HealthPass is the status when all checks passes.
+	HealthPass = "pass"
+
+	// HealthWarn is the status when only checks that are not critical fails.
+	HealthWarn = "warn"
+
+	// HealthFail is the status when a critical check fails or the service is not ready.
+	HealthFail = "fail"
+)
+
+// Check is a named health check of a component, e.g a database.
+type Check struct {
+	// Name is the name of the check, e.g "database".
+	Name string
+
+	// Check returns a error when the component is unhealthy.
+	Check func(ctx context.Context) error
+
+	// Timeout is the maximum duration of the check, 5 seconds is used if zero.
+	Timeout time.Duration
+
+	// Critical checks responds with 503 Service Unavailable when they fail,
+	// other checks are reported with the warn status.
+	Critical bool
+
+	// Live checks are also run by the liveness endpoint. Only checks that
+	// requires a restart of the process to recover should be live checks.
+	Live bool
+}
+
+// CheckStatus is the result of a check.
+type CheckStatus struct {
+	Status   string `json:"status"`
+	Critical bool   `json:"critical"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+// HealthStatus is the aggregated result of the checks.
+type HealthStatus struct {
+	Status string                  `json:"status"`
+	Checks map[string]*CheckStatus `json:"checks,omitempty"`
+}
+
+// Health contains the health checks of a service. The results of the checks are
+// cached so frequent requests to the endpoints don't overload the components.
+type Health struct {
+	// CacheTTL is how long the result of a check is cached, 1 second is used if zero.
+	CacheTTL time.Duration
+
+	// Ready reports whether the service is ready to receive requests, e.g Server.Ready.
+	// The readiness endpoint fails when it returns false.
+	Ready func() bool
+
+	mu     sync.Mutex
+	checks []*healthCheck
+}
+
+// healthCheck is a check with its cached result.
+type healthCheck struct {
+	Check
+
+	mu      sync.Mutex
+	checked time.Time
+	status  *CheckStatus
+}
+
+// NewHealth creates a new health without any checks.
+func NewHealth() *Health {
+	return &Health{}
+}
+
+// Add adds a check. Checks with the same name are replaced.
+func (h *Health) Add(c Check) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, hc := range h.checks {
+		if hc.Name == c.Name {
+			h.checks[i] = &healthCheck{Check: c}
+			return
+		}
+	}
+
+	h.checks = append(h.checks, &healthCheck{Check: c})
+}
+
+// Status runs the checks in parallel, or uses their cached results, and returns the
+// aggregated status. Only live checks are run when live is true.
+func (h *Health) Status(live bool) *HealthStatus {
+	h.mu.Lock()
+	checks := append([]*healthCheck(nil), h.checks...)
+	h.mu.Unlock()
+
+	ttl := h.CacheTTL
+	if ttl <= 0 {
+		ttl = time.Second
+	}
+
+	res := &HealthStatus{
+		Status: HealthPass,
+		Checks: make(map[string]*CheckStatus),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, c := range checks {
+		if live && !c.Live {
+			continue
+		}
+
+		wg.Add(1)
+		go func(c *healthCheck) {
+			defer wg.Done()
+
+			status := c.run(ttl)
+
+			mu.Lock()
+			res.Checks[c.Name] = status
+			mu.Unlock()
+		}(c)
+	}
+
+	wg.Wait()
+
+	for _, status := range res.Checks {
+		switch {
+		case status.Status == HealthPass:
+		case status.Critical:
+			res.Status = HealthFail
+		case res.Status == HealthPass:
+			res.Status = HealthWarn
+		}
+	}
+
+	return res
+}
+
+// run runs the check unless the cached result is still fresh. Concurrent
+// calls waits for the same run instead of running the check again.
+func (c *healthCheck) run(ttl time.Duration) *CheckStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.status != nil && time.Since(c.checked) < ttl {
+		return c.status
+	}
+
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- errors.New("check panicked")
+			}
+		}()
+
+		done <- c.Check.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = errors.New("check timed out")
+	}
+
+	c.status = &CheckStatus{
+		Status:   HealthPass,
+		Critical: c.Critical,
+		Duration: time.Since(start).String(),
+	}
+
+	if err != nil {
+		c.status.Status = HealthFail
+		c.status.Error = err.Error()
+	}
+
+	c.checked = time.Now()
+
+	return c.status
+}
+
+// Health registers the health endpoints of the health on the router:
+//
+//	/healthz runs all checks.
+//	/livez runs the live checks.
+//	/readyz runs all checks and fails when the service is not ready.
+//
+// The endpoints responds with the aggregated status and the status of each check as JSON,
+// with 503 Service Unavailable if the status is fail and 200 OK otherwise. They are
+// plain handlers, so the settings, response handle and middlewares of the router don't
+// apply to them, and they are not part of Routes.
+func (r *Router) Health(h *Health) {
+	endpoint := func(live, ready bool) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			status := h.Status(live)
+
+			if ready && h.Ready != nil && !h.Ready() {
+				status.Status = HealthFail
+			}
+
+			code := http.StatusOK
+			if status.Status == HealthFail {
+				code = http.StatusServiceUnavailable
+			}
+
+			w.Header().Set("Cache-Control", "no-store")
+			writeJSON(w, code, status)
+		})
+	}
+
+	r.handle("GET", r.joinPath("/healthz"), endpoint(false, false))
+	r.handle("GET", r.joinPath("/livez"), endpoint(true, false))
+	r.handle("GET", r.joinPath("/readyz"), endpoint(false, true))
+}
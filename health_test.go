@@ -0,0 +1,120 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHealth(t *testing.T) {
+	var calls int32
+	ready := true
+
+	h := NewHealth()
+	h.CacheTTL = time.Minute
+	h.Ready = func() bool {
+		return ready
+	}
+	h.Add(Check{
+		Name:     "database",
+		Critical: true,
+		Live:     true,
+		Check: func(ctx context.Context) error {
+			atomic.AddInt32(&calls, 1)
+			return nil
+		},
+	})
+	h.Add(Check{
+		Name: "cache",
+		Check: func(ctx context.Context) error {
+			return errors.New("connection refused")
+		},
+	})
+	h.Add(Check{
+		Name:     "search",
+		Critical: true,
+		Timeout:  5 * time.Millisecond,
+		Check: func(ctx context.Context) error {
+			<-ctx.Done()
+			time.Sleep(5 * time.Millisecond)
+			return nil
+		},
+	})
+
+	router := NewRouter()
+	router.Settings.ETag = StrongETag
+	router.Settings.Auth = func(r *http.Request) error {
+		return NewError(http.StatusUnauthorized, "")
+	}
+	router.ResponseHandle = func(fn HandleFunc) Handle {
+		return func(w http.ResponseWriter, r *http.Request, ps Params) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+	})
+	router.Health(h)
+
+	get := func(path string) (int, *HealthStatus) {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", path, nil)
+		router.ServeHTTP(w, r)
+
+		var status HealthStatus
+		if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+			t.Fatalf("%s: invalid body: %s", path, w.Body.String())
+		}
+
+		return w.Code, &status
+	}
+
+	code, status := get("/healthz")
+	if code != http.StatusServiceUnavailable || status.Status != HealthFail {
+		t.Errorf("/healthz: wrong status: got %d %s", code, status.Status)
+	}
+
+	if c := status.Checks["search"]; c == nil || c.Error != "check timed out" {
+		t.Errorf("/healthz: wrong search check: got %+v", c)
+	}
+
+	if c := status.Checks["cache"]; c == nil || c.Status != HealthFail || c.Critical {
+		t.Errorf("/healthz: wrong cache check: got %+v", c)
+	}
+
+	code, status = get("/livez")
+	if code != http.StatusOK || status.Status != HealthPass || len(status.Checks) != 1 {
+		t.Errorf("/livez: wrong status: got %d %+v", code, status)
+	}
+
+	h.Add(Check{
+		Name:     "search",
+		Critical: true,
+		Check: func(ctx context.Context) error {
+			return nil
+		},
+	})
+
+	code, status = get("/readyz")
+	if code != http.StatusOK || status.Status != HealthWarn {
+		t.Errorf("/readyz: wrong status: got %d %s", code, status.Status)
+	}
+
+	ready = false
+
+	code, status = get("/readyz")
+	if code != http.StatusServiceUnavailable || status.Status != HealthFail {
+		t.Errorf("/readyz: wrong status when not ready: got %d %s", code, status.Status)
+	}
+
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("cached check was called %d times", n)
+	}
+}
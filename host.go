@@ -0,0 +1,106 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// hostPattern is a host with placeholders, e.g "{tenant}.api.example.com".
+type hostPattern struct {
+	re    *regexp.Regexp
+	names []string
+}
+
+// newHostPattern parses a host pattern. Placeholders matches a single host label.
+func newHostPattern(host string) *hostPattern {
+	var names []string
+	var expr bytes.Buffer
+
+	pattern := host
+
+	expr.WriteString("^")
+
+	for pattern != "" {
+		start := strings.IndexByte(pattern, '{')
+		if start < 0 {
+			expr.WriteString(regexp.QuoteMeta(pattern))
+			break
+		}
+
+		end := strings.IndexByte(pattern[start:], '}')
+		if end < 0 {
+			panic("missing '}' in host '" + host + "'")
+		}
+		end += start
+
+		name := pattern[start+1 : end]
+		if name == "" {
+			panic("placeholders must be named with a non-empty name in host '" + host + "'")
+		}
+
+		expr.WriteString(regexp.QuoteMeta(pattern[:start]))
+		expr.WriteString(`([^.]+)`)
+		names = append(names, name)
+		pattern = pattern[end+1:]
+	}
+
+	expr.WriteString("$")
+
+	return &hostPattern{
+		re:    regexp.MustCompile("(?i)" + expr.String()),
+		names: names,
+	}
+}
+
+// match matches the host and returns the placeholder values as params.
+func (h *hostPattern) match(host string) (Params, bool) {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+
+	m := h.re.FindStringSubmatch(host)
+	if m == nil {
+		return nil, false
+	}
+
+	ps := make(Params, len(h.names))
+	for i, name := range h.names {
+		ps[i] = Param{Key: name, Value: m[i+1]}
+	}
+
+	return ps, true
+}
+
+// Host returns new *Router whose handles will only be used for requests to
+// the given host. The host can contain placeholders, e.g "{tenant}.api.example.com",
+// which values are added after the path params.
+func (r *Router) Host(host string) *Router {
+	pattern := newHostPattern(host)
+
+	g := r.Group("/")
+	g.matchers = append(append([]matcher(nil), g.matchers...), func(req *http.Request) bool {
+		_, ok := pattern.match(req.Host)
+		return ok
+	})
+
+	if len(pattern.names) > 0 {
+		g.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if hps, ok := pattern.match(req.Host); ok {
+					ps := append(append(Params(nil), ParamsFromContext(req.Context())...), hps...)
+					req = req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params(ps)))
+				}
+
+				next.ServeHTTP(w, req)
+			})
+		})
+	}
+
+	return g
+}
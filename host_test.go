@@ -0,0 +1,60 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHost(t *testing.T) {
+	router := NewRouter()
+	router.Get("/users/:id", func(ps Params) (interface{}, interface{}) {
+		return "default " + ps.ByName("id"), nil
+	})
+
+	router.Host("admin.example.com").Get("/users/:id", func(ps Params) (interface{}, interface{}) {
+		return "admin " + ps.ByName("id"), nil
+	})
+
+	router.Host("{tenant}.api.example.com").Get("/users/:id", func(ps Params) (interface{}, interface{}) {
+		return ps.ByName("tenant") + " " + ps.ByName("id"), nil
+	})
+
+	tests := []struct {
+		host string
+		body string
+	}{
+		{"example.com", `"default 1"`},
+		{"admin.example.com", `"admin 1"`},
+		{"ADMIN.example.com:8080", `"admin 1"`},
+		{"acme.api.example.com", `"acme 1"`},
+		{"acme.foo.api.example.com", `"default 1"`},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/users/1", nil)
+		r.Host = test.host
+		router.ServeHTTP(w, r)
+
+		if w.Body.String() != test.body {
+			t.Errorf("wrong body for %s: want %s, got %s", test.host, test.body, w.Body.String())
+		}
+	}
+}
+
+func TestHostNotFound(t *testing.T) {
+	router := NewRouter()
+	router.Host("admin.example.com").Get("/", func() (interface{}, interface{}) {
+		return nil, nil
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.Host = "example.com"
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("wrong status: want %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
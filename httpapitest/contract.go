@@ -0,0 +1,149 @@
+package httpapitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/frozzare/go-httpapi"
+	"github.com/frozzare/go-httpapi/openapi"
+)
+
+// CheckContract checks that the router follows the OpenAPI document. Routes that
+// isn't documented, without the base path of the servers, is reported, and the requests together with the example
+// requests of the document is served with the router and validated against the
+// operation of the matching path, see ExampleRequests. Responses with undocumented
+// status codes or bodies that don't match the schema is reported.
+func CheckContract(t testing.TB, router *httpapi.Router, doc *openapi.Document, requests ...*http.Request) {
+	t.Helper()
+
+	for _, route := range router.Routes() {
+		if doc.Lookup(route.Method, route.Path) == nil {
+			t.Errorf("%s %s: undocumented route", route.Method, route.Path)
+		}
+	}
+
+	for _, req := range append(requests, ExampleRequests(doc)...) {
+		checkRequest(t, router, doc, req)
+	}
+}
+
+// checkRequest serves the request with the router and validates the request and response.
+func checkRequest(t testing.TB, router http.Handler, doc *openapi.Document, req *http.Request) {
+	t.Helper()
+
+	name := req.Method + " " + req.URL.RequestURI()
+
+	route, params := doc.Find(req.Method, req.URL.Path)
+	if route == nil {
+		t.Errorf("%s: no documented operation", name)
+		return
+	}
+
+	var body []byte
+	if req.Body != nil {
+		body, _ = ioutil.ReadAll(req.Body)
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	for _, v := range doc.ValidateRequest(req, route, params, body) {
+		t.Errorf("%s: request violates %s %s: %s", name, route.Method, route.Path, v)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	for _, v := range doc.ValidateResponse(route, w.Code, w.Header(), w.Body.Bytes()) {
+		t.Errorf("%s: response %d violates %s %s: %s", name, w.Code, route.Method, route.Path, v)
+	}
+}
+
+// ExampleRequests returns requests built from the examples of the document, with the
+// base path of the first server. A request is built for each operation where all path params and required
+// query params has examples, with the example of the JSON request body if any.
+func ExampleRequests(doc *openapi.Document) []*http.Request {
+	var requests []*http.Request
+
+	for _, route := range doc.Routes() {
+		if req, ok := exampleRequest(doc, route); ok {
+			requests = append(requests, req)
+		}
+	}
+
+	return requests
+}
+
+// exampleRequest builds a request for the route from examples.
+func exampleRequest(doc *openapi.Document, route *openapi.Route) (*http.Request, bool) {
+	path := doc.BasePaths()[0] + route.Path
+	query := make(url.Values)
+	header := make(http.Header)
+
+	for _, p := range doc.Parameters(route) {
+		example := p.Example
+		if example == nil {
+			if s := doc.Schema(p.Schema); s != nil {
+				example = s.Example
+			}
+		}
+
+		if example == nil {
+			if p.In == "path" || p.Required {
+				return nil, false
+			}
+			continue
+		}
+
+		value := fmt.Sprint(example)
+
+		switch p.In {
+		case "path":
+			path = strings.Replace(path, "{"+p.Name+"}", url.PathEscape(value), -1)
+		case "query":
+			query.Set(p.Name, value)
+		case "header":
+			header.Set(p.Name, value)
+		}
+	}
+
+	var body []byte
+	if rb := doc.RequestBody(route.Operation.RequestBody); rb != nil {
+		for typ, mt := range rb.Content {
+			example := mt.Example
+			if example == nil {
+				if s := doc.Schema(mt.Schema); s != nil {
+					example = s.Example
+				}
+			}
+
+			if example == nil || typ != "application/json" && !strings.HasSuffix(typ, "+json") {
+				continue
+			}
+
+			body, _ = json.Marshal(example)
+			header.Set("Content-Type", typ)
+			break
+		}
+
+		if body == nil && rb.Required {
+			return nil, false
+		}
+	}
+
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	req := httptest.NewRequest(route.Method, path, bytes.NewReader(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	return req, true
+}
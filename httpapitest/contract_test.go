@@ -0,0 +1,143 @@
+package httpapitest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/frozzare/go-httpapi"
+	"github.com/frozzare/go-httpapi/openapi"
+)
+
+const contractDocument = `{
+	"openapi": "3.0.3",
+	"info": {"title": "Users", "version": "1.0.0"},
+	"paths": {
+		"/users/{id}": {
+			"get": {
+				"parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}, "example": "1"}],
+				"responses": {
+					"200": {
+						"description": "user",
+						"content": {"application/json": {"schema": {
+							"type": "object",
+							"required": ["id", "name"],
+							"properties": {"id": {"type": "string"}, "name": {"type": "string"}}
+						}}}
+					}
+				}
+			}
+		},
+		"/users": {
+			"post": {
+				"requestBody": {"required": true, "content": {"application/json": {"example": {"id": "2", "name": "elli"}}}},
+				"responses": {"201": {"description": "created"}}
+			}
+		}
+	}
+}`
+
+func TestCheckContract(t *testing.T) {
+	doc, err := openapi.Load([]byte(contractDocument))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := httpapi.NewRouter()
+	router.Get("/users/:id", func(ps httpapi.Params) (interface{}, interface{}) {
+		return &user{ID: ps.ByName("id"), Name: "fredrik"}, nil
+	})
+	router.Post("/users", func(c *httpapi.Context) (interface{}, error) {
+		var u user
+		if err := c.Bind(&u); err != nil {
+			return nil, err
+		}
+		return c.Respond(http.StatusCreated, nil), nil
+	})
+
+	CheckContract(t, router, doc, httptest.NewRequest("GET", "/users/3", nil))
+}
+
+func TestCheckContractBasePath(t *testing.T) {
+	doc, err := openapi.Load([]byte(contractDocument))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.Servers = []*openapi.Server{{URL: "https://example.com/api/v1"}}
+
+	router := httpapi.NewRouter()
+	api := router.Group("/api/v1")
+	api.Get("/users/:id", func(ps httpapi.Params) (interface{}, interface{}) {
+		return &user{ID: ps.ByName("id"), Name: "fredrik"}, nil
+	})
+	api.Post("/users", func(c *httpapi.Context) (interface{}, error) {
+		return c.Respond(http.StatusCreated, nil), nil
+	})
+	api.Get("/teams", func() (interface{}, interface{}) {
+		return nil, nil
+	})
+
+	rec := &recorder{}
+	CheckContract(rec, router, doc, httptest.NewRequest("GET", "/api/v1/users/3", nil))
+
+	want := []string{"GET /api/v1/teams: undocumented route"}
+	if strings.Join(rec.errors, "\n") != strings.Join(want, "\n") {
+		t.Errorf("wrong errors:\nwant %q\ngot  %q", want, rec.errors)
+	}
+
+	if r := ExampleRequests(doc)[1]; r.URL.Path != "/api/v1/users/1" {
+		t.Errorf("example request without base path: got %s", r.URL.Path)
+	}
+}
+
+func TestCheckContractViolations(t *testing.T) {
+	doc, err := openapi.Load([]byte(contractDocument))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := httpapi.NewRouter()
+	router.Get("/users/:id", func(ps httpapi.Params) (interface{}, interface{}) {
+		return map[string]string{"id": ps.ByName("id")}, nil
+	})
+	router.Post("/users", func() (interface{}, interface{}) {
+		return nil, nil
+	})
+	router.Delete("/users/:id", func() (interface{}, interface{}) {
+		return nil, nil
+	})
+
+	rec := &recorder{}
+	CheckContract(rec, router, doc)
+
+	want := []string{
+		"DELETE /users/:id: undocumented route",
+		"POST /users: response 200 violates POST /users: status: undocumented status code 200",
+		"GET /users/1: response 200 violates GET /users/{id}: body name: is required",
+	}
+
+	if strings.Join(rec.errors, "\n") != strings.Join(want, "\n") {
+		t.Errorf("wrong errors:\nwant %q\ngot  %q", want, rec.errors)
+	}
+}
+
+func TestExampleRequests(t *testing.T) {
+	doc, err := openapi.Load([]byte(contractDocument))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests := ExampleRequests(doc)
+	if len(requests) != 2 {
+		t.Fatalf("wrong number of requests: want 2, got %d", len(requests))
+	}
+
+	if r := requests[1]; r.Method != "GET" || r.URL.Path != "/users/1" {
+		t.Errorf("wrong request: got %s %s", r.Method, r.URL.Path)
+	}
+
+	if r := requests[0]; r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("wrong request: got %s %s", r.Method, r.Header.Get("Content-Type"))
+	}
+}
@@ -0,0 +1,274 @@
+// Package httpapitest provides a in-process test client for routers and other http.Handlers.
+//
+//	c := httpapitest.NewClient(t, router)
+//
+//	var user User
+//	c.Get("/users/:id").Param("id", "1").Do().
+//		ExpectStatus(http.StatusOK).
+//		ExpectHeader("Content-Type", "application/json").
+//		DecodeJSON(&user)
+package httpapitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Client builds requests and serves them with a handler without opening sockets.
+type Client struct {
+	t       testing.TB
+	handler http.Handler
+	header  http.Header
+}
+
+// NewClient creates a new client for the handler, e.g a *httpapi.Router.
+// Failed requests and assertions are reported to t.
+func NewClient(t testing.TB, handler http.Handler) *Client {
+	return &Client{
+		t:       t,
+		handler: handler,
+		header:  make(http.Header),
+	}
+}
+
+// SetHeader sets a header that is added to all requests made by the client.
+func (c *Client) SetHeader(key, value string) *Client {
+	c.header.Set(key, value)
+	return c
+}
+
+// BearerToken sets a bearer token that is added to all requests made by the client.
+func (c *Client) BearerToken(token string) *Client {
+	return c.SetHeader("Authorization", "Bearer "+token)
+}
+
+// Get creates a GET request.
+func (c *Client) Get(path string) *Request {
+	return c.NewRequest("GET", path)
+}
+
+// Head creates a HEAD request.
+func (c *Client) Head(path string) *Request {
+	return c.NewRequest("HEAD", path)
+}
+
+// Post creates a POST request.
+func (c *Client) Post(path string) *Request {
+	return c.NewRequest("POST", path)
+}
+
+// Put creates a PUT request.
+func (c *Client) Put(path string) *Request {
+	return c.NewRequest("PUT", path)
+}
+
+// Patch creates a PATCH request.
+func (c *Client) Patch(path string) *Request {
+	return c.NewRequest("PATCH", path)
+}
+
+// Delete creates a DELETE request.
+func (c *Client) Delete(path string) *Request {
+	return c.NewRequest("DELETE", path)
+}
+
+// NewRequest creates a request with the given method and path. The path can
+// contain params, e.g "/users/:id", that is replaced with the values set with Param.
+func (c *Client) NewRequest(method, path string) *Request {
+	header := make(http.Header)
+	for k, v := range c.header {
+		header[k] = append([]string(nil), v...)
+	}
+
+	return &Request{
+		client: c,
+		method: method,
+		path:   path,
+		params: make(map[string]string),
+		query:  make(url.Values),
+		header: header,
+	}
+}
+
+// Request is a request that is built fluently and served with Do.
+type Request struct {
+	client *Client
+	method string
+	path   string
+	params map[string]string
+	query  url.Values
+	header http.Header
+	body   io.Reader
+	err    error
+}
+
+// Param sets the value of a path param, e.g "id" for "/users/:id".
+func (r *Request) Param(name, value string) *Request {
+	r.params[name] = value
+	return r
+}
+
+// Query adds a query param.
+func (r *Request) Query(key, value string) *Request {
+	r.query.Add(key, value)
+	return r
+}
+
+// Header sets a request header.
+func (r *Request) Header(key, value string) *Request {
+	r.header.Set(key, value)
+	return r
+}
+
+// BearerToken sets the Authorization header to a bearer token.
+func (r *Request) BearerToken(token string) *Request {
+	return r.Header("Authorization", "Bearer "+token)
+}
+
+// BasicAuth sets the Authorization header to basic authentication.
+func (r *Request) BasicAuth(username, password string) *Request {
+	req := &http.Request{Header: make(http.Header)}
+	req.SetBasicAuth(username, password)
+	return r.Header("Authorization", req.Header.Get("Authorization"))
+}
+
+// Body sets the request body.
+func (r *Request) Body(body io.Reader) *Request {
+	r.body = body
+	return r
+}
+
+// JSON sets the request body to v encoded as JSON and the content type to application/json.
+func (r *Request) JSON(v interface{}) *Request {
+	js, err := json.Marshal(v)
+	if err != nil {
+		r.err = err
+		return r
+	}
+
+	r.body = bytes.NewReader(js)
+	return r.Header("Content-Type", "application/json")
+}
+
+// URL returns the request URL with params replaced and the query params added.
+func (r *Request) URL() string {
+	parts := strings.Split(r.path, "/")
+	for i, part := range parts {
+		if len(part) > 1 && (part[0] == ':' || part[0] == '*') {
+			if v, ok := r.params[part[1:]]; ok {
+				parts[i] = url.PathEscape(v)
+			}
+		}
+	}
+
+	u := strings.Join(parts, "/")
+	if len(r.query) > 0 {
+		u += "?" + r.query.Encode()
+	}
+
+	return u
+}
+
+// Do serves the request with the handler of the client and returns the response.
+func (r *Request) Do() *Response {
+	t := r.client.t
+	t.Helper()
+
+	if r.err != nil {
+		t.Fatalf("%s %s: %v", r.method, r.path, r.err)
+	}
+
+	req := httptest.NewRequest(r.method, r.URL(), r.body)
+	req.Header = r.header
+
+	w := httptest.NewRecorder()
+	r.client.handler.ServeHTTP(w, req)
+
+	return &Response{
+		t:                t,
+		request:          r.method + " " + req.URL.RequestURI(),
+		ResponseRecorder: w,
+	}
+}
+
+// Response is a response with assertions. Failed assertions are reported to the test.
+type Response struct {
+	*httptest.ResponseRecorder
+	t       testing.TB
+	request string
+}
+
+// ExpectStatus asserts the status code of the response.
+func (r *Response) ExpectStatus(status int) *Response {
+	r.t.Helper()
+
+	if r.Code != status {
+		r.t.Errorf("%s: wrong status: want %d, got %d: %s", r.request, status, r.Code, r.Body.String())
+	}
+
+	return r
+}
+
+// ExpectHeader asserts the value of a response header.
+func (r *Response) ExpectHeader(key, value string) *Response {
+	r.t.Helper()
+
+	if v := r.Header().Get(key); v != value {
+		r.t.Errorf("%s: wrong %s header: want %q, got %q", r.request, key, value, v)
+	}
+
+	return r
+}
+
+// ExpectBody asserts the body of the response.
+func (r *Response) ExpectBody(body string) *Response {
+	r.t.Helper()
+
+	if r.Body.String() != body {
+		r.t.Errorf("%s: wrong body: want %q, got %q", r.request, body, r.Body.String())
+	}
+
+	return r
+}
+
+// ExpectJSON asserts that the body is JSON equal to v encoded as JSON.
+func (r *Response) ExpectJSON(v interface{}) *Response {
+	r.t.Helper()
+
+	js, err := json.Marshal(v)
+	if err != nil {
+		r.t.Fatalf("%s: %v", r.request, err)
+	}
+
+	var want, got interface{}
+	json.Unmarshal(js, &want)
+
+	if err := json.Unmarshal(r.Body.Bytes(), &got); err != nil {
+		r.t.Errorf("%s: invalid JSON body: %v: %s", r.request, err, r.Body.String())
+		return r
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		r.t.Errorf("%s: wrong JSON body: want %s, got %s", r.request, js, r.Body.String())
+	}
+
+	return r
+}
+
+// DecodeJSON decodes the JSON body into v.
+func (r *Response) DecodeJSON(v interface{}) *Response {
+	r.t.Helper()
+
+	if err := json.Unmarshal(r.Body.Bytes(), v); err != nil {
+		r.t.Errorf("%s: invalid JSON body: %v: %s", r.request, err, r.Body.String())
+	}
+
+	return r
+}
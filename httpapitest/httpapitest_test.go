@@ -0,0 +1,90 @@
+package httpapitest
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/frozzare/go-httpapi"
+)
+
+type user struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// recorder records failed assertions instead of failing the test.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.Errorf(format, args...)
+}
+
+func newRouter() *httpapi.Router {
+	router := httpapi.NewRouter()
+	router.Get("/users/:id", func(r *http.Request, ps httpapi.Params) (interface{}, interface{}) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			return nil, httpapi.NewError(http.StatusUnauthorized, "")
+		}
+		return &user{ID: ps.ByName("id"), Name: r.URL.Query().Get("name")}, nil
+	})
+	router.Post("/users", func(c *httpapi.Context) (interface{}, error) {
+		var u user
+		if err := c.Bind(&u); err != nil {
+			return nil, err
+		}
+		return c.Created("/users/"+u.ID, u), nil
+	})
+
+	return router
+}
+
+func TestClient(t *testing.T) {
+	c := NewClient(t, newRouter()).BearerToken("secret")
+
+	var u user
+	c.Get("/users/:id").Param("id", "1").Query("name", "fredrik").Do().
+		ExpectStatus(http.StatusOK).
+		ExpectHeader("Content-Type", "application/json").
+		ExpectJSON(map[string]string{"id": "1", "name": "fredrik"}).
+		DecodeJSON(&u)
+
+	if u.ID != "1" || u.Name != "fredrik" {
+		t.Errorf("wrong decoded user: got %+v", u)
+	}
+
+	c.Post("/users").JSON(&user{ID: "2", Name: "elli"}).Do().
+		ExpectStatus(http.StatusCreated).
+		ExpectHeader("Location", "/users/2").
+		ExpectBody(`{"id":"2","name":"elli"}`)
+
+	NewClient(t, newRouter()).Get("/users/1").Do().
+		ExpectStatus(http.StatusUnauthorized)
+}
+
+func TestClientFailures(t *testing.T) {
+	rec := &recorder{}
+	c := NewClient(rec, newRouter())
+
+	c.Get("/users/:id").Param("id", "1").BearerToken("secret").Do().
+		ExpectStatus(http.StatusNotFound).
+		ExpectHeader("Content-Type", "text/plain").
+		ExpectJSON(map[string]string{"id": "2"})
+
+	if len(rec.errors) != 3 {
+		t.Fatalf("wrong number of failed assertions: want 3, got %d: %v", len(rec.errors), rec.errors)
+	}
+
+	if want := "GET /users/1: wrong status: want 404, got 200"; rec.errors[0][:len(want)] != want {
+		t.Errorf("wrong failure: want %q, got %q", want, rec.errors[0])
+	}
+}
@@ -0,0 +1,323 @@
+package httpapi
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// IdempotencyRecord is a stored request with a Idempotency-Key header and its response.
+type IdempotencyRecord struct {
+	// Fingerprint is a hash of the method, path, query and body of the request.
+	Fingerprint string
+
+	// Completed is false while the request is in-flight.
+	Completed bool
+
+	// Status, Header and Body is the response of the completed request.
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// IdempotencyStore stores the records of requests with a Idempotency-Key header.
+// Stores that are shared between processes, e.g Redis, must reserve keys atomically.
+type IdempotencyStore interface {
+	// Reserve stores the in-flight record for the key if the key don't exist and returns true.
+	// Otherwise the existing record is returned with false.
+	Reserve(key string, record *IdempotencyRecord) (*IdempotencyRecord, bool, error)
+
+	// Complete replaces the in-flight record of the key with the completed record.
+	Complete(key string, record *IdempotencyRecord) error
+
+	// Release removes the record of the key, so the request can be retried.
+	Release(key string) error
+}
+
+// IdempotencyOptions configures the idempotency handler.
+type IdempotencyOptions struct {
+	// Store stores the records, a memory store that keeps records for 24 hours is used if nil.
+	Store IdempotencyStore
+
+	// Methods is the methods that honors the Idempotency-Key header, POST and PATCH is used if empty.
+	Methods []string
+
+	// Required responds with 400 Bad Request to requests without a Idempotency-Key header.
+	Required bool
+
+	// Scope returns the scope of the key, e.g the authenticated user, so clients can't
+	// replay the responses of each other. It's called after Settings.Auth.
+	Scope func(r *http.Request) string
+
+	// MaxBodySize is the maximum size of request bodies that is read to fingerprint
+	// the request, 1 MB is used if zero. Larger bodies is responded with
+	// 413 Request Entity Too Large.
+	MaxBodySize int64
+
+	// ErrorHandle responds with the errors of the idempotency handler.
+	// The error handle from the settings of the route is used if it's nil.
+	ErrorHandle func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// Idempotency returns a handler step that honors the Idempotency-Key header, it should be
+// used as Settings.Idempotency so it runs after the body limit, Auth and Validate. The response
+// of the first request with a key is stored and replayed for retries with the same key,
+// with the Idempotent-Replayed header set. A retry while the first request is in-flight is
+// responded with 409 Conflict, and a retry with a different method, path, query or body is
+// responded with 422 Unprocessable Entity. Responses with 5xx status codes is not stored, so
+// the request can be retried.
+func Idempotency(opts IdempotencyOptions) func(http.Handler) http.Handler {
+	if opts.Store == nil {
+		opts.Store = NewMemoryIdempotencyStore(24 * time.Hour)
+	}
+
+	if len(opts.Methods) == 0 {
+		opts.Methods = []string{"POST", "PATCH"}
+	}
+
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = 1 << 20
+	}
+
+	handleError := func(w http.ResponseWriter, r *http.Request, err error) {
+		if opts.ErrorHandle != nil {
+			opts.ErrorHandle(w, r, err)
+		} else {
+			settingsFromRequest(r).handleError(w, r, err)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !contains(opts.Methods, r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := r.Header.Get("Idempotency-Key")
+			if key == "" {
+				if opts.Required {
+					handleError(w, r, NewError(http.StatusBadRequest, "missing Idempotency-Key header"))
+					return
+				}
+
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if opts.Scope != nil {
+				key = opts.Scope(r) + ":" + key
+			}
+
+			if r.ContentLength > opts.MaxBodySize {
+				handleError(w, r, bodyTooLarge(opts.MaxBodySize))
+				return
+			}
+
+			var body []byte
+			if r.Body != nil {
+				var err error
+				if body, err = ioutil.ReadAll(io.LimitReader(r.Body, opts.MaxBodySize+1)); err != nil {
+					if StatusCode(err) == 0 {
+						err = NewError(http.StatusBadRequest, err.Error())
+					}
+					handleError(w, r, err)
+					return
+				}
+
+				if int64(len(body)) > opts.MaxBodySize {
+					handleError(w, r, bodyTooLarge(opts.MaxBodySize))
+					return
+				}
+
+				r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			}
+
+			sum := sha256.Sum256([]byte(r.Method + " " + r.URL.Path + "?" + r.URL.RawQuery + "\n" + string(body)))
+			fingerprint := hex.EncodeToString(sum[:])
+
+			existing, ok, err := opts.Store.Reserve(key, &IdempotencyRecord{Fingerprint: fingerprint})
+			if err != nil {
+				handleError(w, r, err)
+				return
+			}
+
+			if !ok {
+				switch {
+				case existing.Fingerprint != fingerprint:
+					handleError(w, r, NewError(http.StatusUnprocessableEntity, "Idempotency-Key is already used for a different request"))
+				case !existing.Completed:
+					handleError(w, r, NewError(http.StatusConflict, "a request with the Idempotency-Key is in progress"))
+				default:
+					for k, v := range existing.Header {
+						w.Header()[k] = v
+					}
+					w.Header().Set("Idempotent-Replayed", "true")
+					w.WriteHeader(existing.Status)
+					w.Write(existing.Body)
+				}
+				return
+			}
+
+			iw := &idempotencyWriter{ResponseWriter: w}
+			completed := false
+
+			// Release the key if next panics, so the request can be retried.
+			defer func() {
+				if !completed {
+					opts.Store.Release(key)
+				}
+			}()
+
+			next.ServeHTTP(iw, r)
+
+			status := iw.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			if status >= 500 {
+				return
+			}
+
+			header := iw.header
+			if header == nil {
+				header = cloneHeader(w.Header())
+			}
+
+			completed = opts.Store.Complete(key, &IdempotencyRecord{
+				Fingerprint: fingerprint,
+				Completed:   true,
+				Status:      status,
+				Header:      header,
+				Body:        iw.body.Bytes(),
+			}) == nil
+		})
+	}
+}
+
+// idempotencyWriter is a http.ResponseWriter that records the status, headers and body of the response.
+type idempotencyWriter struct {
+	http.ResponseWriter
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+// WriteHeader records the status and headers before writing them.
+func (w *idempotencyWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+		w.header = cloneHeader(w.ResponseWriter.Header())
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the data before writing it.
+func (w *idempotencyWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	w.body.Write(p)
+
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush flushes the underlying writer if it's a http.Flusher.
+func (w *idempotencyWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// cloneHeader returns a copy of the header.
+func cloneHeader(h http.Header) http.Header {
+	c := make(http.Header, len(h))
+	for k, v := range h {
+		c[k] = append([]string(nil), v...)
+	}
+
+	return c
+}
+
+// MemoryIdempotencyStore is a IdempotencyStore that keeps the records in memory.
+// Expired records is removed at most once a minute, or once per TTL if it's shorter.
+type MemoryIdempotencyStore struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	records map[string]*memoryIdempotencyRecord
+	swept   time.Time
+}
+
+// memoryIdempotencyRecord is a record with the time it expires.
+type memoryIdempotencyRecord struct {
+	record  IdempotencyRecord
+	expires time.Time
+}
+
+// NewMemoryIdempotencyStore creates a new memory store that keeps the records for the duration.
+func NewMemoryIdempotencyStore(ttl time.Duration) *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		ttl:     ttl,
+		records: make(map[string]*memoryIdempotencyRecord),
+	}
+}
+
+// Reserve stores the in-flight record for the key if the key don't exist or has expired.
+func (s *MemoryIdempotencyStore) Reserve(key string, record *IdempotencyRecord) (*IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+
+	// Remove expired records now and then so the map don't grow forever.
+	interval := time.Minute
+	if s.ttl < interval {
+		interval = s.ttl
+	}
+
+	if now.Sub(s.swept) >= interval {
+		for k, r := range s.records {
+			if now.After(r.expires) {
+				delete(s.records, k)
+			}
+		}
+		s.swept = now
+	}
+
+	if r, ok := s.records[key]; ok && !now.After(r.expires) {
+		existing := r.record
+		return &existing, false, nil
+	}
+
+	s.records[key] = &memoryIdempotencyRecord{record: *record, expires: now.Add(s.ttl)}
+
+	return nil, true, nil
+}
+
+// Complete replaces the in-flight record of the key.
+func (s *MemoryIdempotencyStore) Complete(key string, record *IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[key] = &memoryIdempotencyRecord{record: *record, expires: time.Now().Add(s.ttl)}
+
+	return nil
+}
+
+// Release removes the record of the key.
+func (s *MemoryIdempotencyStore) Release(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+
+	return nil
+}
@@ -0,0 +1,249 @@
+package httpapi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdempotency(t *testing.T) {
+	calls := 0
+	inFlight := make(chan struct{})
+	release := make(chan struct{})
+
+	var postQuery func(key, query, body string) *httptest.ResponseRecorder
+
+	router := NewRouter()
+	router.Settings.Idempotency = Idempotency(IdempotencyOptions{})
+	router.Post("/orders", func(c *Context) (interface{}, error) {
+		calls++
+
+		var order map[string]interface{}
+		if err := c.Bind(&order); err != nil {
+			return nil, err
+		}
+
+		if order["wait"] == true {
+			close(inFlight)
+			<-release
+		}
+
+		if order["fail"] == true {
+			return nil, NewError(http.StatusInternalServerError, "failed")
+		}
+
+		return c.Created("/orders/1", order), nil
+	})
+
+	post := func(key, body string) *httptest.ResponseRecorder {
+		return postQuery(key, "", body)
+	}
+
+	postQuery = func(key, query, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/orders"+query, strings.NewReader(body))
+		if key != "" {
+			r.Header.Set("Idempotency-Key", key)
+		}
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	first := post("a", `{"amount":10}`)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("wrong status: want %d, got %d", http.StatusCreated, first.Code)
+	}
+
+	retry := post("a", `{"amount":10}`)
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Errorf("wrong replayed response: got %d %s", retry.Code, retry.Body.String())
+	}
+
+	if retry.Header().Get("Location") != "/orders/1" || retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("wrong replayed headers: got %v", retry.Header())
+	}
+
+	if calls != 1 {
+		t.Errorf("handle was called %d times", calls)
+	}
+
+	if w := post("a", `{"amount":20}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("wrong status for different payload: want %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+
+	if w := postQuery("a", "?dry=1", `{"amount":10}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("wrong status for different query: want %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+
+	post("", `{"amount":10}`)
+	if calls != 2 {
+		t.Errorf("request without key was not handled: %d calls", calls)
+	}
+
+	if w := post("b", `{"fail":true}`); w.Code != http.StatusInternalServerError {
+		t.Errorf("wrong status: want %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+
+	post("b", `{"fail":true}`)
+	if calls != 4 {
+		t.Errorf("failed request was not retried: %d calls", calls)
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		done <- post("c", `{"wait":true}`)
+	}()
+
+	select {
+	case <-inFlight:
+	case <-time.After(time.Second):
+		t.Fatal("request did not start")
+	}
+
+	if w := post("c", `{"wait":true}`); w.Code != http.StatusConflict {
+		t.Errorf("wrong status for in-flight request: want %d, got %d", http.StatusConflict, w.Code)
+	}
+
+	close(release)
+
+	if w := <-done; w.Code != http.StatusCreated {
+		t.Errorf("wrong status: want %d, got %d", http.StatusCreated, w.Code)
+	}
+}
+
+func TestIdempotencyAuth(t *testing.T) {
+	calls := 0
+
+	router := NewRouter()
+	router.Settings.Auth = func(r *http.Request) error {
+		if r.Header.Get("Authorization") != "Bearer valid" {
+			return errors.New("invalid token")
+		}
+		return nil
+	}
+	router.Settings.Idempotency = Idempotency(IdempotencyOptions{
+		Scope: func(r *http.Request) string {
+			return r.Header.Get("Authorization")
+		},
+	})
+	router.Post("/payments", func() (interface{}, interface{}) {
+		calls++
+		return calls, nil
+	})
+
+	post := func(token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("POST", "/payments", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		r.Header.Set("Idempotency-Key", "a")
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := post("invalid"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong status: want %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	w := post("valid")
+	if w.Code != http.StatusOK || w.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("unauthorized response was replayed: got %d %s", w.Code, w.Body.String())
+	}
+
+	if w := post("valid"); w.Header().Get("Idempotent-Replayed") != "true" || calls != 1 {
+		t.Errorf("response was not replayed: %d calls", calls)
+	}
+}
+
+func TestIdempotencyRequired(t *testing.T) {
+	router := NewRouter()
+	router.Settings.Idempotency = Idempotency(IdempotencyOptions{Required: true})
+	router.Post("/orders", func() (interface{}, interface{}) {
+		return nil, nil
+	})
+	router.Put("/orders", func() (interface{}, interface{}) {
+		return nil, nil
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/orders", nil)
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("wrong status: want %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("PUT", "/orders", nil)
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("wrong status for PUT: want %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestIdempotencyOptions(t *testing.T) {
+	calls := 0
+
+	router := NewRouter()
+	router.Settings.Idempotency = Idempotency(IdempotencyOptions{
+		Required:    true,
+		MaxBodySize: 10,
+		ErrorHandle: func(w http.ResponseWriter, r *http.Request, err error) {
+			w.WriteHeader(http.StatusTeapot)
+		},
+	})
+	router.Post("/orders", func() (interface{}, interface{}) {
+		calls++
+		return nil, nil
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/orders", nil)
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusTeapot {
+		t.Errorf("error handle was not used: got %d", w.Code)
+	}
+
+	router = NewRouter()
+	router.Settings.Idempotency = Idempotency(IdempotencyOptions{MaxBodySize: 10})
+	router.Post("/orders", func() (interface{}, interface{}) {
+		calls++
+		return nil, nil
+	})
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("POST", "/orders", strings.NewReader(strings.Repeat("a", 1<<20)))
+	r.ContentLength = -1
+	r.Header.Set("Idempotency-Key", "a")
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("wrong status: want %d, got %d", http.StatusRequestEntityTooLarge, w.Code)
+	}
+
+	if calls != 0 {
+		t.Errorf("handle was called %d times", calls)
+	}
+}
+
+func TestMemoryIdempotencyStore(t *testing.T) {
+	s := NewMemoryIdempotencyStore(time.Millisecond)
+
+	if _, ok, _ := s.Reserve("a", &IdempotencyRecord{Fingerprint: "1"}); !ok {
+		t.Fatal("key was not reserved")
+	}
+
+	if existing, ok, _ := s.Reserve("a", &IdempotencyRecord{Fingerprint: "2"}); ok || existing.Fingerprint != "1" {
+		t.Errorf("key was reserved twice")
+	}
+
+	time.Sleep(2 * time.Millisecond)
+
+	if _, ok, _ := s.Reserve("a", &IdempotencyRecord{Fingerprint: "2"}); !ok {
+		t.Error("expired key was not reserved")
+	}
+}
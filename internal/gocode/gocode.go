@@ -0,0 +1,103 @@
+// Package gocode contains helpers that is shared by the code generators.
+package gocode
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// initialisms contains initialisms that is written in upper case in Go names.
+var initialisms = map[string]bool{
+	"API":  true,
+	"HTTP": true,
+	"ID":   true,
+	"IP":   true,
+	"JSON": true,
+	"SQL":  true,
+	"URI":  true,
+	"URL":  true,
+	"UUID": true,
+}
+
+// Name converts a name, e.g "user_id" or "getUser", to a exported Go name, e.g "UserID" or "GetUser".
+func Name(s string) string {
+	var words []string
+	var word []rune
+	prevLower := false
+
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			prevLower = false
+			continue
+		}
+
+		if unicode.IsUpper(r) && prevLower {
+			flush()
+		}
+
+		word = append(word, r)
+		prevLower = unicode.IsLower(r) || unicode.IsDigit(r)
+	}
+	flush()
+
+	var buf bytes.Buffer
+	for _, w := range words {
+		if u := strings.ToUpper(w); initialisms[u] {
+			buf.WriteString(u)
+			continue
+		}
+
+		r := []rune(w)
+		buf.WriteString(string(unicode.ToUpper(r[0])) + string(r[1:]))
+	}
+
+	name := buf.String()
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		name = "X" + name
+	}
+
+	return name
+}
+
+// WriteImports writes a import declaration of the packages, with standard library
+// packages grouped before other packages. The map contains the import paths and
+// their package names, a name is only written when it's not the last element of the path.
+func WriteImports(buf *bytes.Buffer, imports map[string]string) {
+	paths := make([]string, 0, len(imports))
+	for imp := range imports {
+		paths = append(paths, imp)
+	}
+	sort.Strings(paths)
+
+	buf.WriteString("import (\n")
+	for _, std := range []bool{true, false} {
+		for _, imp := range paths {
+			if strings.Contains(imp, ".") == std {
+				continue
+			}
+
+			if name := imports[imp]; name != "" && name != path.Base(imp) {
+				fmt.Fprintf(buf, "%s %q\n", name, imp)
+			} else {
+				fmt.Fprintf(buf, "%q\n", imp)
+			}
+		}
+
+		if std {
+			buf.WriteString("\n")
+		}
+	}
+	buf.WriteString(")\n\n")
+}
@@ -0,0 +1,38 @@
+package gocode
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestName(t *testing.T) {
+	tests := map[string]string{
+		"user":         "User",
+		"user_id":      "UserID",
+		"getUserById":  "GetUserByID",
+		"X-Request-ID": "XRequestID",
+		"api-url":      "APIURL",
+		"2fa":          "X2fa",
+	}
+
+	for in, want := range tests {
+		if got := Name(in); got != want {
+			t.Errorf("%s: want %s, got %s", in, want, got)
+		}
+	}
+}
+
+func TestWriteImports(t *testing.T) {
+	var buf bytes.Buffer
+	WriteImports(&buf, map[string]string{
+		"net/http":                       "http",
+		"context":                        "",
+		"github.com/frozzare/go-httpapi": "httpapi",
+		"example.com/api/v2":             "apiv2",
+	})
+
+	want := "import (\n\"context\"\n\"net/http\"\n\napiv2 \"example.com/api/v2\"\nhttpapi \"github.com/frozzare/go-httpapi\"\n)\n\n"
+	if buf.String() != want {
+		t.Errorf("wrong imports:\nwant %q\ngot  %q", want, buf.String())
+	}
+}
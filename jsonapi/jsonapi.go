@@ -0,0 +1,231 @@
+// Package jsonapi provides a response handle for httpapi that responds with JSON:API documents.
+//
+// Structs are mapped to resource objects with `jsonapi` struct tags:
+//
+//	type Article struct {
+//		ID     string  `jsonapi:"primary,articles"`
+//		Title  string  `jsonapi:"attr,title"`
+//		Author *Person `jsonapi:"relation,author,omitempty"`
+//	}
+//
+// Read more: https://jsonapi.org/format/
+package jsonapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/frozzare/go-httpapi"
+)
+
+// MediaType is the JSON:API media type.
+const MediaType = "application/vnd.api+json"
+
+// Links is a links object.
+type Links map[string]interface{}
+
+// Meta is a meta object.
+type Meta map[string]interface{}
+
+// Linkable can be implemented by structs to add links to their resource object.
+type Linkable interface {
+	JSONAPILinks() Links
+}
+
+// Metable can be implemented by structs to add meta to their resource object.
+type Metable interface {
+	JSONAPIMeta() Meta
+}
+
+// Document is a JSON:API top level document.
+type Document struct {
+	Data     json.RawMessage `json:"data,omitempty"`
+	Included []*Resource     `json:"included,omitempty"`
+	Errors   []*ErrorObject  `json:"errors,omitempty"`
+	Links    Links           `json:"links,omitempty"`
+	Meta     Meta            `json:"meta,omitempty"`
+}
+
+// Resource is a JSON:API resource object.
+type Resource struct {
+	Type          string                   `json:"type"`
+	ID            string                   `json:"id,omitempty"`
+	Attributes    map[string]interface{}   `json:"attributes,omitempty"`
+	Relationships map[string]*Relationship `json:"relationships,omitempty"`
+	Links         Links                    `json:"links,omitempty"`
+	Meta          Meta                     `json:"meta,omitempty"`
+}
+
+// Identifier is a JSON:API resource identifier object.
+type Identifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// Relationship is a JSON:API relationship object.
+// Data is nil, a *Identifier or a []*Identifier.
+type Relationship struct {
+	Data  interface{} `json:"data"`
+	Links Links       `json:"links,omitempty"`
+	Meta  Meta        `json:"meta,omitempty"`
+}
+
+// ErrorObject is a JSON:API error object.
+type ErrorObject struct {
+	ID     string                 `json:"id,omitempty"`
+	Status string                 `json:"status,omitempty"`
+	Code   string                 `json:"code,omitempty"`
+	Title  string                 `json:"title,omitempty"`
+	Detail string                 `json:"detail,omitempty"`
+	Source map[string]interface{} `json:"source,omitempty"`
+	Meta   Meta                   `json:"meta,omitempty"`
+}
+
+// Error implements the error interface.
+func (e *ErrorObject) Error() string {
+	if e.Detail != "" {
+		return e.Detail
+	}
+
+	return e.Title
+}
+
+// StatusCode returns the HTTP status code of the error object.
+func (e *ErrorObject) StatusCode() int {
+	status, _ := strconv.Atoi(e.Status)
+	return status
+}
+
+// ErrorObjects can be implemented by errors to respond with multiple error objects.
+type ErrorObjects interface {
+	JSONAPIErrors() []*ErrorObject
+}
+
+// ResponseHandle is a httpapi response handle that responds with JSON:API documents.
+// Data returned from handles is marshaled with Marshal and errors are mapped to error objects.
+// The status and headers of a returned *httpapi.Response is used and its data is marshaled.
+func ResponseHandle(fn httpapi.HandleFunc) httpapi.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httpapi.Params) {
+		data, out := fn(r, ps)
+
+		if out == nil {
+			status := http.StatusOK
+
+			if res, ok := data.(*httpapi.Response); ok {
+				for k, v := range res.Header {
+					w.Header()[k] = v
+				}
+
+				if res.Status != 0 {
+					status = res.Status
+				}
+
+				if res.Data == nil {
+					w.WriteHeader(status)
+					return
+				}
+
+				data = res.Data
+			}
+
+			doc, err := marshalData(r, data)
+			if err != nil {
+				writeErrors(w, err)
+				return
+			}
+
+			write(w, status, doc)
+			return
+		}
+
+		if err, ok := out.(error); ok {
+			writeErrors(w, err)
+		}
+	}
+}
+
+// marshalData marshals data returned from a handle, pages are marshaled with links and meta.
+func marshalData(r *http.Request, data interface{}) (*Document, error) {
+	p, ok := data.(*httpapi.Page)
+	if !ok {
+		return Marshal(data)
+	}
+
+	doc, err := Marshal(p.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Total != nil {
+		doc.Meta = Meta{"total": *p.Total}
+	}
+
+	for _, l := range p.Links(r) {
+		if doc.Links == nil {
+			doc.Links = Links{}
+		}
+		doc.Links[l.Rel] = l.URL
+	}
+
+	return doc, nil
+}
+
+// Errors maps a error to error objects.
+func Errors(err error) []*ErrorObject {
+	switch e := err.(type) {
+	case ErrorObjects:
+		return e.JSONAPIErrors()
+	case *ErrorObject:
+		return []*ErrorObject{e}
+	}
+
+	status := httpapi.StatusCode(err)
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+
+	return []*ErrorObject{{
+		Status: strconv.Itoa(status),
+		Title:  http.StatusText(status),
+		Detail: err.Error(),
+	}}
+}
+
+// writeErrors writes a document with the error objects of the error.
+func writeErrors(w http.ResponseWriter, err error) {
+	errs := Errors(err)
+
+	// Use the most general status code when error objects has different status codes.
+	status := 0
+	for _, e := range errs {
+		s := e.StatusCode()
+		switch {
+		case status == 0:
+			status = s
+		case status != s && s >= 500:
+			status = http.StatusInternalServerError
+		case status != s && status < 500:
+			status = http.StatusBadRequest
+		}
+	}
+
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+
+	write(w, status, &Document{Errors: errs})
+}
+
+// write writes the document with the given status code.
+func write(w http.ResponseWriter, status int, doc *Document) {
+	js, err := json.Marshal(doc)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", MediaType)
+	w.WriteHeader(status)
+	w.Write(js)
+}
@@ -0,0 +1,222 @@
+package jsonapi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/frozzare/go-httpapi"
+)
+
+type person struct {
+	ID   int    `jsonapi:"primary,people"`
+	Name string `jsonapi:"attr,name"`
+}
+
+type comment struct {
+	ID   string `jsonapi:"primary,comments"`
+	Body string `jsonapi:"attr,body"`
+}
+
+type article struct {
+	ID       string     `jsonapi:"primary,articles"`
+	Title    string     `jsonapi:"attr,title"`
+	Draft    bool       `jsonapi:"attr,draft,omitempty"`
+	Author   *person    `jsonapi:"relation,author"`
+	Comments []*comment `jsonapi:"relation,comments,omitempty"`
+}
+
+func (a *article) JSONAPILinks() Links {
+	return Links{"self": "/articles/" + a.ID}
+}
+
+func TestResponseHandle(t *testing.T) {
+	router := httpapi.NewRouter()
+	router.ResponseHandle = ResponseHandle
+	router.Get("/articles/:id", func(ps httpapi.Params) (interface{}, interface{}) {
+		return &article{
+			ID:       ps.ByName("id"),
+			Title:    "JSON:API",
+			Author:   &person{ID: 9, Name: "Fredrik"},
+			Comments: []*comment{{ID: "5", Body: "First!"}},
+		}, nil
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/articles/1", nil)
+	router.ServeHTTP(w, r)
+
+	want := `{"data":{"type":"articles","id":"1","attributes":{"title":"JSON:API"},` +
+		`"relationships":{"author":{"data":{"type":"people","id":"9"}},"comments":{"data":[{"type":"comments","id":"5"}]}},` +
+		`"links":{"self":"/articles/1"}},` +
+		`"included":[{"type":"people","id":"9","attributes":{"name":"Fredrik"}},{"type":"comments","id":"5","attributes":{"body":"First!"}}]}`
+
+	if w.Body.String() != want {
+		t.Errorf("wrong body:\nwant %s\ngot  %s", want, w.Body.String())
+	}
+
+	if ct := w.Header().Get("Content-Type"); ct != MediaType {
+		t.Errorf("wrong content type: got %s", ct)
+	}
+}
+
+func TestResponseHandleResponse(t *testing.T) {
+	router := httpapi.NewRouter()
+	router.ResponseHandle = ResponseHandle
+	router.Post("/people", func(c *httpapi.Context) (interface{}, error) {
+		return c.Created("/people/9", &person{ID: 9, Name: "Fredrik"}), nil
+	})
+	router.Delete("/people/:id", func(c *httpapi.Context) (interface{}, error) {
+		return c.Respond(http.StatusNoContent, nil), nil
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/people", nil)
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusCreated || w.Header().Get("Location") != "/people/9" {
+		t.Errorf("wrong response: got %d %v", w.Code, w.Header())
+	}
+
+	if want := `{"data":{"type":"people","id":"9","attributes":{"name":"Fredrik"}}}`; w.Body.String() != want {
+		t.Errorf("wrong body:\nwant %s\ngot  %s", want, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("DELETE", "/people/9", nil)
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Errorf("wrong response: got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestResponseHandleErrors(t *testing.T) {
+	router := httpapi.NewRouter()
+	router.ResponseHandle = ResponseHandle
+	router.Get("/not-found", func() (interface{}, interface{}) {
+		return nil, httpapi.NewError(http.StatusNotFound, "article not found")
+	})
+	router.Get("/error", func() (interface{}, interface{}) {
+		return nil, errors.New("fail")
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/not-found", http.StatusNotFound, `{"errors":[{"status":"404","title":"Not Found","detail":"article not found"}]}`},
+		{"/error", http.StatusInternalServerError, `{"errors":[{"status":"500","title":"Internal Server Error","detail":"fail"}]}`},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", test.path, nil)
+		router.ServeHTTP(w, r)
+
+		if w.Code != test.status {
+			t.Errorf("wrong status for %s: want %d, got %d", test.path, test.status, w.Code)
+		}
+
+		if w.Body.String() != test.body {
+			t.Errorf("wrong body for %s: want %s, got %s", test.path, test.body, w.Body.String())
+		}
+	}
+}
+
+func TestMarshalPage(t *testing.T) {
+	router := httpapi.NewRouter()
+	router.ResponseHandle = ResponseHandle
+	router.Get("/people", func(r *http.Request) (interface{}, interface{}) {
+		q, _ := httpapi.ParseListQuery(r, httpapi.ListOptions{DefaultLimit: 1})
+		p := httpapi.NewPage(q, []person{{ID: 1, Name: "Fredrik"}})
+		p.SetTotal(1)
+		return p, nil
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/people", nil)
+	router.ServeHTTP(w, r)
+
+	want := `{"data":[{"type":"people","id":"1","attributes":{"name":"Fredrik"}}],` +
+		`"links":{"first":"/people?page=1","last":"/people?page=1"},"meta":{"total":1}}`
+	if w.Body.String() != want {
+		t.Errorf("wrong body:\nwant %s\ngot  %s", want, w.Body.String())
+	}
+}
+
+func TestDecode(t *testing.T) {
+	body := `{"data":{"type":"articles","id":"1","attributes":{"title":"JSON:API","draft":true},` +
+		`"relationships":{"author":{"data":{"type":"people","id":"9"}},"comments":{"data":[{"type":"comments","id":"5"}]}}},` +
+		`"included":[{"type":"comments","id":"5","attributes":{"body":"First!"}}]}`
+
+	r, _ := http.NewRequest("POST", "/articles", strings.NewReader(body))
+	r.Header.Set("Content-Type", MediaType)
+
+	var a article
+	if err := Decode(r, &a); err != nil {
+		t.Fatal(err)
+	}
+
+	if a.ID != "1" || a.Title != "JSON:API" || !a.Draft {
+		t.Errorf("wrong attributes: got %+v", a)
+	}
+
+	if a.Author == nil || a.Author.ID != 9 {
+		t.Errorf("wrong author: got %+v", a.Author)
+	}
+
+	if len(a.Comments) != 1 || a.Comments[0].Body != "First!" {
+		t.Errorf("wrong comments: got %+v", a.Comments)
+	}
+}
+
+func TestUnmarshalCyclic(t *testing.T) {
+	type friend struct {
+		ID     int     `jsonapi:"primary,people"`
+		Name   string  `jsonapi:"attr,name"`
+		Friend *friend `jsonapi:"relation,friend"`
+	}
+
+	body := `{"data":{"type":"people","id":"1","attributes":{"name":"A"},"relationships":{"friend":{"data":{"type":"people","id":"2"}}}},` +
+		`"included":[{"type":"people","id":"2","attributes":{"name":"B"},"relationships":{"friend":{"data":{"type":"people","id":"2"}}}},` +
+		`{"type":"people","id":"1","attributes":{"name":"A"},"relationships":{"friend":{"data":{"type":"people","id":"2"}}}}]}`
+
+	var p friend
+	if err := Unmarshal([]byte(body), &p); err != nil {
+		t.Fatal(err)
+	}
+
+	if p.Friend == nil || p.Friend.ID != 2 || p.Friend.Name != "B" {
+		t.Fatalf("wrong friend: got %+v", p.Friend)
+	}
+
+	if f := p.Friend.Friend; f == nil || f.ID != 2 || f.Name != "" || f.Friend != nil {
+		t.Errorf("cyclic friend was not a identifier: got %+v", f)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        string
+		status      int
+	}{
+		{"application/json", `{}`, http.StatusUnsupportedMediaType},
+		{MediaType, `{"data":{"type":"people","id":"1"}}`, http.StatusBadRequest},
+		{MediaType, `{`, http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		r, _ := http.NewRequest("POST", "/articles", strings.NewReader(test.body))
+		r.Header.Set("Content-Type", test.contentType)
+
+		var a article
+		if err := Decode(r, &a); httpapi.StatusCode(err) != test.status {
+			t.Errorf("wrong status for %s: want %d, got %v", test.body, test.status, err)
+		}
+	}
+}
@@ -0,0 +1,519 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/frozzare/go-httpapi"
+)
+
+// field is a struct field with a jsonapi tag.
+type field struct {
+	index     int
+	kind      string
+	name      string
+	omitempty bool
+}
+
+// fields returns the fields with jsonapi tags of a struct type.
+func fields(t reflect.Type) ([]field, error) {
+	var fs []field
+
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("jsonapi")
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("jsonapi: invalid tag %q on field %s.%s", tag, t.Name(), t.Field(i).Name)
+		}
+
+		f := field{
+			index: i,
+			kind:  parts[0],
+			name:  parts[1],
+		}
+
+		for _, p := range parts[2:] {
+			if p == "omitempty" {
+				f.omitempty = true
+			}
+		}
+
+		switch f.kind {
+		case "primary", "attr", "relation":
+		default:
+			return nil, fmt.Errorf("jsonapi: invalid tag %q on field %s.%s", tag, t.Name(), t.Field(i).Name)
+		}
+
+		fs = append(fs, f)
+	}
+
+	return fs, nil
+}
+
+// indirect dereferences pointers and interfaces until a non pointer value or nil is found.
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return v
+		}
+		v = v.Elem()
+	}
+
+	return v
+}
+
+// isEmpty reports whether the value is the zero value of its type.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
+
+// marshaler marshals resources and collects included resources.
+type marshaler struct {
+	primary  map[Identifier]bool
+	seen     map[Identifier]bool
+	included []*Resource
+}
+
+// Marshal marshals a struct or a slice of structs with jsonapi tags to a document.
+func Marshal(v interface{}) (*Document, error) {
+	m := &marshaler{
+		primary: make(map[Identifier]bool),
+		seen:    make(map[Identifier]bool),
+	}
+	rv := indirect(reflect.ValueOf(v))
+
+	var data interface{}
+
+	switch {
+	case !rv.IsValid() || rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface:
+		data = nil
+	case rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array:
+		resources := make([]*Resource, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			res, err := m.resource(indirect(rv.Index(i)), true)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, res)
+		}
+		data = resources
+	case rv.Kind() == reflect.Struct:
+		res, err := m.resource(rv, true)
+		if err != nil {
+			return nil, err
+		}
+		data = res
+	default:
+		return nil, fmt.Errorf("jsonapi: unsupported type %s", rv.Type())
+	}
+
+	js, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Remove included resources that is in the primary data.
+	included := m.included[:0]
+	for _, res := range m.included {
+		if !m.primary[Identifier{Type: res.Type, ID: res.ID}] {
+			included = append(included, res)
+		}
+	}
+
+	return &Document{
+		Data:     js,
+		Included: included,
+	}, nil
+}
+
+// resource marshals a struct to a resource object.
+func (m *marshaler) resource(v reflect.Value, primary bool) (*Resource, error) {
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("jsonapi: unsupported type %s", v.Type())
+	}
+
+	fs, err := fields(v.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Resource{}
+
+	for _, f := range fs {
+		if f.kind == "primary" {
+			res.Type = f.name
+			res.ID = formatID(v.Field(f.index))
+		}
+	}
+
+	if res.Type == "" {
+		return nil, fmt.Errorf("jsonapi: missing primary tag on %s", v.Type())
+	}
+
+	if primary {
+		m.primary[Identifier{Type: res.Type, ID: res.ID}] = true
+	}
+
+	for _, f := range fs {
+		fv := v.Field(f.index)
+
+		switch f.kind {
+		case "attr":
+			if f.omitempty && isEmpty(fv) {
+				continue
+			}
+
+			if res.Attributes == nil {
+				res.Attributes = make(map[string]interface{})
+			}
+			res.Attributes[f.name] = fv.Interface()
+		case "relation":
+			if f.omitempty && isEmpty(fv) {
+				continue
+			}
+
+			rel, err := m.relationship(fv)
+			if err != nil {
+				return nil, err
+			}
+
+			if res.Relationships == nil {
+				res.Relationships = make(map[string]*Relationship)
+			}
+			res.Relationships[f.name] = rel
+		}
+	}
+
+	iv := v.Interface()
+	if v.CanAddr() {
+		iv = v.Addr().Interface()
+	}
+
+	if l, ok := iv.(Linkable); ok {
+		res.Links = l.JSONAPILinks()
+	}
+
+	if mt, ok := iv.(Metable); ok {
+		res.Meta = mt.JSONAPIMeta()
+	}
+
+	return res, nil
+}
+
+// relationship marshals a relation field and includes the related resources.
+func (m *marshaler) relationship(v reflect.Value) (*Relationship, error) {
+	v = indirect(v)
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Invalid:
+		return &Relationship{}, nil
+	case reflect.Slice, reflect.Array:
+		ids := make([]*Identifier, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			id, err := m.include(indirect(v.Index(i)))
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+		return &Relationship{Data: ids}, nil
+	}
+
+	id, err := m.include(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Relationship{Data: id}, nil
+}
+
+// include marshals a related struct to a included resource and returns its identifier.
+func (m *marshaler) include(v reflect.Value) (*Identifier, error) {
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("jsonapi: unsupported relation type %s", v.Type())
+	}
+
+	fs, err := fields(v.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	id := &Identifier{}
+	for _, f := range fs {
+		if f.kind == "primary" {
+			id.Type = f.name
+			id.ID = formatID(v.Field(f.index))
+		}
+	}
+
+	if m.seen[*id] {
+		return id, nil
+	}
+	m.seen[*id] = true
+
+	res, err := m.resource(v, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only include resources that has more than a identifier.
+	if len(res.Attributes) > 0 || len(res.Relationships) > 0 {
+		m.included = append(m.included, res)
+	}
+
+	return id, nil
+}
+
+// formatID formats a primary field value as a string.
+func formatID(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	}
+
+	return fmt.Sprint(v.Interface())
+}
+
+// parseID sets a primary field value from a string.
+func parseID(v reflect.Value, id string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(id)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return fmt.Errorf("jsonapi: invalid id %q", id)
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			return fmt.Errorf("jsonapi: invalid id %q", id)
+		}
+		v.SetUint(i)
+	default:
+		return fmt.Errorf("jsonapi: unsupported id type %s", v.Type())
+	}
+
+	return nil
+}
+
+// Unmarshal unmarshals a JSON:API document into a struct or a slice of structs with jsonapi tags.
+// Related resources are unmarshaled from the included resources when they exist.
+func Unmarshal(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("jsonapi: Unmarshal requires a non-nil pointer")
+	}
+
+	var doc struct {
+		Data     json.RawMessage `json:"data"`
+		Included []*Resource     `json:"included"`
+	}
+
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+
+	u := &unmarshaler{
+		included:  make(map[Identifier]*Resource),
+		resolving: make(map[Identifier]bool),
+	}
+	for _, res := range doc.Included {
+		u.included[Identifier{Type: res.Type, ID: res.ID}] = res
+	}
+
+	rv = rv.Elem()
+
+	if rv.Kind() == reflect.Slice {
+		var resources []*Resource
+		if err := json.Unmarshal(doc.Data, &resources); err != nil {
+			return err
+		}
+
+		s := reflect.MakeSlice(rv.Type(), len(resources), len(resources))
+		for i, res := range resources {
+			if err := u.resource(res, s.Index(i)); err != nil {
+				return err
+			}
+		}
+		rv.Set(s)
+
+		return nil
+	}
+
+	var res *Resource
+	if err := json.Unmarshal(doc.Data, &res); err != nil {
+		return err
+	}
+
+	if res == nil {
+		return errors.New("jsonapi: missing primary data")
+	}
+
+	return u.resource(res, rv)
+}
+
+// unmarshaler unmarshals resources with included resources.
+type unmarshaler struct {
+	included  map[Identifier]*Resource
+	resolving map[Identifier]bool
+}
+
+// resource unmarshals a resource object into a struct value, allocating pointers as needed.
+func (u *unmarshaler) resource(res *Resource, v reflect.Value) error {
+	// Mark the resource while it's resolved so cyclic relationships is not followed.
+	if res.ID != "" {
+		id := Identifier{Type: res.Type, ID: res.ID}
+		if !u.resolving[id] {
+			u.resolving[id] = true
+			defer delete(u.resolving, id)
+		}
+	}
+
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("jsonapi: unsupported type %s", v.Type())
+	}
+
+	fs, err := fields(v.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fs {
+		fv := v.Field(f.index)
+
+		switch f.kind {
+		case "primary":
+			if res.Type != f.name {
+				return fmt.Errorf("jsonapi: invalid type %q, expected %q", res.Type, f.name)
+			}
+
+			if res.ID != "" {
+				if err := parseID(fv, res.ID); err != nil {
+					return err
+				}
+			}
+		case "attr":
+			attr, ok := res.Attributes[f.name]
+			if !ok {
+				continue
+			}
+
+			js, err := json.Marshal(attr)
+			if err != nil {
+				return err
+			}
+
+			if err := json.Unmarshal(js, fv.Addr().Interface()); err != nil {
+				return fmt.Errorf("jsonapi: invalid attribute %q: %v", f.name, err)
+			}
+		case "relation":
+			rel, ok := res.Relationships[f.name]
+			if !ok || rel.Data == nil {
+				continue
+			}
+
+			if err := u.relationship(rel, fv); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// relationship unmarshals relationship data into a relation field.
+func (u *unmarshaler) relationship(rel *Relationship, v reflect.Value) error {
+	js, err := json.Marshal(rel.Data)
+	if err != nil {
+		return err
+	}
+
+	if v.Kind() == reflect.Slice {
+		var ids []Identifier
+		if err := json.Unmarshal(js, &ids); err != nil {
+			return err
+		}
+
+		s := reflect.MakeSlice(v.Type(), len(ids), len(ids))
+		for i, id := range ids {
+			if err := u.resource(u.lookup(id), s.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+
+		return nil
+	}
+
+	var id Identifier
+	if err := json.Unmarshal(js, &id); err != nil {
+		return err
+	}
+
+	return u.resource(u.lookup(id), v)
+}
+
+// lookup returns the included resource for the identifier or a resource with only the identifier.
+// A resource with only the identifier is also returned when the resource is already being resolved.
+func (u *unmarshaler) lookup(id Identifier) *Resource {
+	if res, ok := u.included[id]; ok && !u.resolving[id] {
+		return res
+	}
+
+	return &Resource{Type: id.Type, ID: id.ID}
+}
+
+// Decode decodes a JSON:API request body into v, see Unmarshal.
+// Errors responds with 415 Unsupported Media Type or 400 Bad Request when returned from a handle.
+func Decode(r *http.Request, v interface{}) error {
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		if mt, _, err := mime.ParseMediaType(ct); err != nil || mt != MediaType {
+			return httpapi.Errorf(http.StatusUnsupportedMediaType, "content type must be %s", MediaType)
+		}
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		if httpapi.StatusCode(err) != 0 {
+			return err
+		}
+		return httpapi.NewError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := Unmarshal(body, v); err != nil {
+		return httpapi.NewError(http.StatusBadRequest, err.Error())
+	}
+
+	return nil
+}
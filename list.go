@@ -0,0 +1,395 @@
+package httpapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Filter operators that can be used in list queries, e.g "filter[age][gte]=18".
+const (
+	FilterEq   = "eq"
+	FilterNe   = "ne"
+	FilterLt   = "lt"
+	FilterLte  = "lte"
+	FilterGt   = "gt"
+	FilterGte  = "gte"
+	FilterIn   = "in"
+	FilterLike = "like"
+)
+
+// filterOps contains all supported filter operators.
+var filterOps = map[string]bool{
+	FilterEq:   true,
+	FilterNe:   true,
+	FilterLt:   true,
+	FilterLte:  true,
+	FilterGt:   true,
+	FilterGte:  true,
+	FilterIn:   true,
+	FilterLike: true,
+}
+
+// Sort represents a field to sort by.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// Filter represents a filter on a field.
+type Filter struct {
+	Field string
+	Op    string
+	Value string
+}
+
+// Values returns the comma separated values of the filter, used by the "in" operator.
+func (f Filter) Values() []string {
+	return strings.Split(f.Value, ",")
+}
+
+// ListQuery represents the standard query parameters of a list endpoint.
+type ListQuery struct {
+	// Cursor is the cursor to list from, from the "cursor" query parameter.
+	Cursor string
+
+	// Page is the page number starting at one, from the "page" query parameter.
+	// Page is one when a cursor is used.
+	Page int
+
+	// Limit is the maximum number of items, from the "limit" query parameter.
+	Limit int
+
+	// Sort contains the fields to sort by, from the "sort" query parameter,
+	// e.g "sort=-created,name" sorts by created descending and name ascending.
+	Sort []Sort
+
+	// Filters contains the field filters, from "filter[field]=value"
+	// and "filter[field][op]=value" query parameters.
+	Filters []Filter
+}
+
+// Offset returns the offset of the first item of the page.
+func (q *ListQuery) Offset() int {
+	return (q.Page - 1) * q.Limit
+}
+
+// Filter returns the first filter for the field and operator, or false if none.
+func (q *ListQuery) Filter(field, op string) (Filter, bool) {
+	for _, f := range q.Filters {
+		if f.Field == field && f.Op == op {
+			return f, true
+		}
+	}
+
+	return Filter{}, false
+}
+
+// ListOptions contains the options used when parsing a list query.
+type ListOptions struct {
+	// DefaultLimit is the limit used when no limit is given. Defaults to 20.
+	DefaultLimit int
+
+	// MaxLimit is the maximum limit. Larger limits are lowered to it. Defaults to 100.
+	MaxLimit int
+
+	// Sortable contains the fields that can be sorted by. Any field can be sorted by if empty.
+	Sortable []string
+
+	// Filterable contains the fields that can be filtered on. Any field can be filtered on if empty.
+	Filterable []string
+}
+
+// contains reports whether the value is in the list or if the list is empty.
+func contains(list []string, v string) bool {
+	if len(list) == 0 {
+		return true
+	}
+
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseListQuery parses the list query parameters of a request.
+// Errors for invalid parameters responds with 400 Bad Request when returned from a handle.
+func ParseListQuery(r *http.Request, opts ListOptions) (*ListQuery, error) {
+	if opts.DefaultLimit <= 0 {
+		opts.DefaultLimit = 20
+	}
+
+	if opts.MaxLimit <= 0 {
+		opts.MaxLimit = 100
+	}
+
+	values := r.URL.Query()
+	q := &ListQuery{
+		Cursor: values.Get("cursor"),
+		Page:   1,
+		Limit:  opts.DefaultLimit,
+	}
+
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return nil, Errorf(http.StatusBadRequest, "invalid value %q for query param %q: must be a positive integer", v, "limit")
+		}
+		q.Limit = limit
+	}
+
+	if q.Limit > opts.MaxLimit {
+		q.Limit = opts.MaxLimit
+	}
+
+	if v := values.Get("page"); v != "" && q.Cursor == "" {
+		page, err := strconv.Atoi(v)
+		if err != nil || page < 1 {
+			return nil, Errorf(http.StatusBadRequest, "invalid value %q for query param %q: must be a positive integer", v, "page")
+		}
+		q.Page = page
+	}
+
+	if v := values.Get("sort"); v != "" {
+		for _, field := range strings.Split(v, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+
+			s := Sort{Field: field}
+			if field[0] == '-' || field[0] == '+' {
+				s.Field, s.Desc = field[1:], field[0] == '-'
+			}
+
+			if !contains(opts.Sortable, s.Field) {
+				return nil, Errorf(http.StatusBadRequest, "invalid sort field %q", s.Field)
+			}
+
+			q.Sort = append(q.Sort, s)
+		}
+	}
+
+	for key, vals := range values {
+		if !strings.HasPrefix(key, "filter[") || len(vals) == 0 {
+			continue
+		}
+
+		f, err := parseFilter(key, vals[0])
+		if err != nil {
+			return nil, err
+		}
+
+		if !contains(opts.Filterable, f.Field) {
+			return nil, Errorf(http.StatusBadRequest, "invalid filter field %q", f.Field)
+		}
+
+		q.Filters = append(q.Filters, f)
+	}
+
+	// Sort the filters so the order don't depend on map iteration.
+	sort.Slice(q.Filters, func(i, j int) bool {
+		a, b := q.Filters[i], q.Filters[j]
+		return a.Field < b.Field || a.Field == b.Field && a.Op < b.Op
+	})
+
+	return q, nil
+}
+
+// parseFilter parses a filter from a "filter[field]" or "filter[field][op]" key.
+func parseFilter(key, value string) (Filter, error) {
+	parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(key, "filter["), "]"), "][")
+
+	f := Filter{
+		Field: parts[0],
+		Op:    FilterEq,
+		Value: value,
+	}
+
+	if len(parts) > 2 || f.Field == "" || !strings.HasSuffix(key, "]") {
+		return f, Errorf(http.StatusBadRequest, "invalid filter %q", key)
+	}
+
+	if len(parts) == 2 {
+		f.Op = parts[1]
+	}
+
+	if !filterOps[f.Op] {
+		return f, Errorf(http.StatusBadRequest, "invalid filter operator %q for field %q", f.Op, f.Field)
+	}
+
+	return f, nil
+}
+
+// Page represents a page of items returned from a list handle.
+// The default response handle responds with the items, metadata and Link headers.
+type Page struct {
+	// Items is the items of the page.
+	Items interface{}
+
+	// Query is the list query the page is for.
+	Query *ListQuery
+
+	// Total is the total number of items, or nil if unknown, see SetTotal.
+	Total *int
+
+	// NextCursor is the cursor of the next page when cursors is used.
+	// There is no next page if it's empty.
+	NextCursor string
+}
+
+// NewPage creates a new page with the given items for the list query.
+func NewPage(q *ListQuery, items interface{}) *Page {
+	return &Page{
+		Items: items,
+		Query: q,
+	}
+}
+
+// SetTotal sets the total number of items.
+func (p *Page) SetTotal(total int) {
+	p.Total = &total
+}
+
+// full reports whether the page has as many items as the limit of the query,
+// so there may be a next page when the total is unknown.
+func (p *Page) full(limit int) bool {
+	v := reflect.ValueOf(p.Items)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return false
+	}
+
+	return v.Len() >= limit
+}
+
+// pageMeta is the metadata of a page.
+type pageMeta struct {
+	Page       int    `json:"page,omitempty"`
+	Limit      int    `json:"limit"`
+	Total      *int   `json:"total,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// pageBody is the body of a page.
+type pageBody struct {
+	Items interface{} `json:"items"`
+	Meta  pageMeta    `json:"meta"`
+}
+
+// render writes the Link headers of the page and returns the body.
+func (p *Page) render(w http.ResponseWriter, r *http.Request) interface{} {
+	q := p.query()
+
+	body := pageBody{
+		Items: p.Items,
+		Meta: pageMeta{
+			Limit:      q.Limit,
+			NextCursor: p.NextCursor,
+		},
+	}
+
+	if body.Items == nil {
+		body.Items = []interface{}{}
+	}
+
+	if p.Total != nil {
+		total := *p.Total
+		body.Meta.Total = &total
+	}
+
+	if q.Cursor == "" && p.NextCursor == "" {
+		body.Meta.Page = q.Page
+	}
+
+	for _, l := range p.Links(r) {
+		w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="%s"`, l.URL, l.Rel))
+	}
+
+	return body
+}
+
+// query returns the list query of the page or a empty query if none.
+func (p *Page) query() *ListQuery {
+	if p.Query == nil {
+		return &ListQuery{Page: 1}
+	}
+
+	return p.Query
+}
+
+// PageLink is a link to another page.
+type PageLink struct {
+	Rel string
+	URL string
+}
+
+// Links returns the links to the first, previous, next and last page, relative to the request URL.
+// Only a next link is returned when cursors is used. When the total is unknown there is no last
+// link, and the next link is returned if the page has as many items as the limit.
+func (p *Page) Links(r *http.Request) []PageLink {
+	var links []PageLink
+
+	q := p.query()
+
+	link := func(rel string, set map[string]string) {
+		u := *r.URL
+		values := u.Query()
+		for k, v := range set {
+			if v == "" {
+				values.Del(k)
+			} else {
+				values.Set(k, v)
+			}
+		}
+		u.RawQuery = values.Encode()
+		links = append(links, PageLink{Rel: rel, URL: u.RequestURI()})
+	}
+
+	if q.Cursor != "" || p.NextCursor != "" {
+		if p.NextCursor != "" {
+			link("next", map[string]string{"cursor": p.NextCursor, "page": ""})
+		}
+		return links
+	}
+
+	if q.Limit <= 0 {
+		return links
+	}
+
+	page := func(n int) map[string]string {
+		return map[string]string{"page": strconv.Itoa(n)}
+	}
+
+	link("first", page(1))
+
+	if q.Page > 1 {
+		link("prev", page(q.Page-1))
+	}
+
+	if p.Total == nil {
+		if p.full(q.Limit) {
+			link("next", page(q.Page+1))
+		}
+
+		return links
+	}
+
+	last := (*p.Total + q.Limit - 1) / q.Limit
+	if last < 1 {
+		last = 1
+	}
+
+	if q.Page < last {
+		link("next", page(q.Page+1))
+	}
+
+	link("last", page(last))
+
+	return links
+}
@@ -0,0 +1,163 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseListQuery(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/issues?page=2&limit=500&sort=-created,name&filter[status]=open&filter[age][gte]=18", nil)
+
+	q, err := ParseListQuery(r, ListOptions{MaxLimit: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if q.Page != 2 || q.Limit != 50 || q.Offset() != 50 {
+		t.Errorf("wrong page: got page %d, limit %d", q.Page, q.Limit)
+	}
+
+	want := []Sort{{"created", true}, {"name", false}}
+	if !reflect.DeepEqual(q.Sort, want) {
+		t.Errorf("wrong sort: want %v, got %v", want, q.Sort)
+	}
+
+	filters := []Filter{{"age", FilterGte, "18"}, {"status", FilterEq, "open"}}
+	if !reflect.DeepEqual(q.Filters, filters) {
+		t.Errorf("wrong filters: want %v, got %v", filters, q.Filters)
+	}
+
+	if f, ok := q.Filter("status", FilterEq); !ok || f.Value != "open" {
+		t.Errorf("wrong filter: got %v", f)
+	}
+}
+
+func TestParseListQueryErrors(t *testing.T) {
+	tests := []string{
+		"/issues?limit=abc",
+		"/issues?page=0",
+		"/issues?sort=secret",
+		"/issues?filter[status][foo]=open",
+		"/issues?filter[secret]=1",
+	}
+
+	for _, test := range tests {
+		r, _ := http.NewRequest("GET", test, nil)
+
+		_, err := ParseListQuery(r, ListOptions{
+			Sortable:   []string{"name"},
+			Filterable: []string{"status"},
+		})
+		if StatusCode(err) != http.StatusBadRequest {
+			t.Errorf("expected bad request error for %s: got %v", test, err)
+		}
+	}
+}
+
+func TestPageResponse(t *testing.T) {
+	router := NewRouter()
+	router.Get("/issues", func(r *http.Request) (interface{}, interface{}) {
+		q, err := ParseListQuery(r, ListOptions{DefaultLimit: 2})
+		if err != nil {
+			return nil, err
+		}
+
+		p := NewPage(q, []string{"a", "b"})
+		p.SetTotal(5)
+		return p, nil
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/issues?page=2", nil)
+	router.ServeHTTP(w, r)
+
+	want := `{"items":["a","b"],"meta":{"page":2,"limit":2,"total":5}}`
+	if w.Body.String() != want {
+		t.Errorf("wrong body: want %s, got %s", want, w.Body.String())
+	}
+
+	links := []string{
+		`</issues?page=1>; rel="first"`,
+		`</issues?page=1>; rel="prev"`,
+		`</issues?page=3>; rel="next"`,
+		`</issues?page=3>; rel="last"`,
+	}
+	if !reflect.DeepEqual(w.Header()["Link"], links) {
+		t.Errorf("wrong links: want %v, got %v", links, w.Header()["Link"])
+	}
+}
+
+func TestPageUnknownTotal(t *testing.T) {
+	router := NewRouter()
+	router.Get("/issues", func(r *http.Request) (interface{}, interface{}) {
+		q, err := ParseListQuery(r, ListOptions{DefaultLimit: 2})
+		if err != nil {
+			return nil, err
+		}
+
+		if q.Page == 1 {
+			return &Page{Query: q, Items: []string{"a", "b"}}, nil
+		}
+
+		return NewPage(q, []string{"c"}), nil
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/issues", nil)
+	router.ServeHTTP(w, r)
+
+	want := `{"items":["a","b"],"meta":{"page":1,"limit":2}}`
+	if w.Body.String() != want {
+		t.Errorf("wrong body: want %s, got %s", want, w.Body.String())
+	}
+
+	links := []string{
+		`</issues?page=1>; rel="first"`,
+		`</issues?page=2>; rel="next"`,
+	}
+	if !reflect.DeepEqual(w.Header()["Link"], links) {
+		t.Errorf("wrong links: want %v, got %v", links, w.Header()["Link"])
+	}
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("GET", "/issues?page=2", nil)
+	router.ServeHTTP(w, r)
+
+	links = []string{
+		`</issues?page=1>; rel="first"`,
+		`</issues?page=1>; rel="prev"`,
+	}
+	if !reflect.DeepEqual(w.Header()["Link"], links) {
+		t.Errorf("wrong links for last page: want %v, got %v", links, w.Header()["Link"])
+	}
+}
+
+func TestPageCursorResponse(t *testing.T) {
+	router := NewRouter()
+	router.Get("/issues", func(r *http.Request) (interface{}, interface{}) {
+		q, err := ParseListQuery(r, ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		p := NewPage(q, nil)
+		p.NextCursor = "def"
+		return p, nil
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/issues?cursor=abc&limit=10", nil)
+	router.ServeHTTP(w, r)
+
+	want := `{"items":[],"meta":{"limit":10,"next_cursor":"def"}}`
+	if w.Body.String() != want {
+		t.Errorf("wrong body: want %s, got %s", want, w.Body.String())
+	}
+
+	link := `</issues?cursor=def&limit=10>; rel="next"`
+	if w.Header().Get("Link") != link {
+		t.Errorf("wrong link: want %s, got %s", link, w.Header().Get("Link"))
+	}
+}
@@ -0,0 +1,157 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/frozzare/go-httpapi"
+)
+
+// ValidationError is the error of a request that violates the document.
+type ValidationError struct {
+	// Status is 422 Unprocessable Entity when only the schema of the body is
+	// violated, otherwise 400 Bad Request.
+	Status int
+
+	// Violations contains the violations of the request.
+	Violations []Violation
+}
+
+// Error returns the violations as a string.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.String()
+	}
+
+	return "request violates the OpenAPI document: " + strings.Join(msgs, ", ")
+}
+
+// StatusCode returns the HTTP status code of the error.
+func (e *ValidationError) StatusCode() int {
+	return e.Status
+}
+
+// ContentType returns the content type of the JSON of the error.
+func (e *ValidationError) ContentType() string {
+	return "application/problem+json"
+}
+
+// MarshalJSON returns the error as a problem details object with the violations.
+// Read more: https://tools.ietf.org/html/rfc7807
+func (e *ValidationError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"type":       "about:blank",
+		"title":      http.StatusText(e.Status),
+		"status":     e.Status,
+		"detail":     "request violates the OpenAPI document",
+		"violations": e.Violations,
+	})
+}
+
+// ValidatorOption configures a validator.
+type ValidatorOption func(*validator)
+
+// validator contains the options of a validator.
+type validator struct {
+	reject       bool
+	undocumented func(r *http.Request)
+}
+
+// RejectUndocumented returns a validator option that responds with 404 Not Found
+// to requests without a documented operation.
+func RejectUndocumented() ValidatorOption {
+	return func(v *validator) {
+		v.reject = true
+	}
+}
+
+// OnUndocumented returns a validator option that calls fn with the requests
+// without a documented operation, e.g to log or count them.
+func OnUndocumented(fn func(r *http.Request)) ValidatorOption {
+	return func(v *validator) {
+		v.undocumented = fn
+	}
+}
+
+// Validator returns a function that validates requests against the operation of
+// the document that matches the request, to be used as the Validate setting of a
+// router. The operation is looked up from the route that matched the request, or
+// from the request path for mounted handlers, without the base path of the servers.
+// Requests without a documented operation is not validated unless the RejectUndocumented
+// option is used. The request body is read and replaced, so the handle can read it again.
+//
+//	router.Settings.Validate = openapi.Validator(doc)
+func Validator(doc *Document, opts ...ValidatorOption) func(r *http.Request) error {
+	v := &validator{}
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	return func(r *http.Request) error {
+		route, params := doc.operation(r)
+		if route == nil {
+			if v.undocumented != nil {
+				v.undocumented(r)
+			}
+
+			if v.reject {
+				return &ValidationError{
+					Status:     http.StatusNotFound,
+					Violations: []Violation{{In: "path", Message: "no documented operation"}},
+				}
+			}
+
+			return nil
+		}
+
+		var body []byte
+		if r.Body != nil && r.Body != http.NoBody {
+			var err error
+			if body, err = ioutil.ReadAll(r.Body); err != nil {
+				return err
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+
+		vs := doc.ValidateRequest(r, route, params, body)
+		if len(vs) == 0 {
+			return nil
+		}
+
+		status := http.StatusUnprocessableEntity
+		for _, v := range vs {
+			if v.In != "body" || v.malformed {
+				status = http.StatusBadRequest
+				break
+			}
+		}
+
+		return &ValidationError{Status: status, Violations: vs}
+	}
+}
+
+// operation returns the documented route of the route that matched the request together
+// with the values of the path params. The request path is used for mounted handlers.
+func (d *Document) operation(r *http.Request) (*Route, map[string]string) {
+	route := httpapi.RouteFromContext(r.Context())
+	if route == nil || route.Method == "" {
+		return d.Find(r.Method, r.URL.Path)
+	}
+
+	op := d.Lookup(route.Method, route.Path)
+	if op == nil {
+		return nil, nil
+	}
+
+	params := make(map[string]string)
+	for _, p := range httpapi.ParamsFromContext(r.Context()) {
+		// Catch-all params begins with a slash.
+		params[p.Key] = strings.TrimPrefix(p.Value, "/")
+	}
+
+	return op, params
+}
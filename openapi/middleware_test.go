@@ -0,0 +1,118 @@
+package openapi
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/frozzare/go-httpapi"
+)
+
+func TestValidator(t *testing.T) {
+	router := httpapi.NewRouter()
+	router.Settings.Validate = Validator(loadTestDocument(t))
+	router.Get("/users", func() (interface{}, interface{}) {
+		return []string{}, nil
+	})
+	router.Post("/users", func(r *http.Request) (interface{}, interface{}) {
+		body, _ := ioutil.ReadAll(r.Body)
+		return string(body), nil
+	})
+	router.Get("/health", func() (interface{}, interface{}) {
+		return "ok", nil
+	})
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		status int
+		resp   string
+	}{
+		{"GET", "/users?limit=10", "", http.StatusOK, "[]"},
+		{"GET", "/health", "", http.StatusOK, `"ok"`},
+		{"GET", "/users?limit=1000", "", http.StatusBadRequest, `"violations":[{"in":"query","field":"limit","message":"must be at most 100"}]`},
+		{"POST", "/users", `{"name":"fredrik"}`, http.StatusOK, `"{\"name\":\"fredrik\"}"`},
+		{"POST", "/users", `{"name":1}`, http.StatusUnprocessableEntity, `"violations":[{"in":"body","field":"name","message":"must be a string"}]`},
+		{"POST", "/users", `{"name":`, http.StatusBadRequest, `"title":"Bad Request"`},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(test.method, test.path, strings.NewReader(test.body))
+		r.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, r)
+
+		if w.Code != test.status {
+			t.Errorf("%s %s: wrong status: want %d, got %d", test.method, test.path, test.status, w.Code)
+		}
+
+		if !strings.Contains(w.Body.String(), test.resp) {
+			t.Errorf("%s %s: wrong body: want %s in %s", test.method, test.path, test.resp, w.Body.String())
+		}
+
+		if test.status >= 400 && w.Header().Get("Content-Type") != "application/problem+json" {
+			t.Errorf("%s %s: wrong content type: got %q", test.method, test.path, w.Header().Get("Content-Type"))
+		}
+	}
+}
+
+func TestValidatorBasePath(t *testing.T) {
+	doc := loadTestDocument(t)
+	doc.Servers = []*Server{{URL: "https://example.com/api/v1/"}}
+
+	var undocumented []string
+
+	router := httpapi.NewRouter()
+	api := router.Group("/api/v1")
+	api.Settings.Validate = Validator(doc, OnUndocumented(func(r *http.Request) {
+		undocumented = append(undocumented, r.URL.Path)
+	}))
+	api.Get("/users", func() (interface{}, interface{}) {
+		return []string{}, nil
+	})
+	api.Get("/teams", func() (interface{}, interface{}) {
+		return []string{}, nil
+	})
+
+	mounted := httpapi.NewRouter()
+	mounted.Settings.Validate = Validator(doc, RejectUndocumented())
+	mounted.Get("/users/:id", func() (interface{}, interface{}) {
+		return nil, nil
+	})
+	mounted.Get("/teams", func() (interface{}, interface{}) {
+		return []string{}, nil
+	})
+
+	mount := httpapi.NewRouter()
+	mount.Mount("/api/v1", mounted)
+
+	tests := []struct {
+		router http.Handler
+		path   string
+		status int
+	}{
+		{router, "/api/v1/users?limit=10", http.StatusOK},
+		{router, "/api/v1/users?limit=1000", http.StatusBadRequest},
+		{router, "/api/v1/teams", http.StatusOK},
+		{mount, "/api/v1/users/c9a8d5f4-5c1b-4a5e-9a3b-1f2e3d4c5b6a", http.StatusOK},
+		{mount, "/api/v1/users/123", http.StatusBadRequest},
+		{mount, "/api/v1/teams", http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", test.path, nil)
+		test.router.ServeHTTP(w, r)
+
+		if w.Code != test.status {
+			t.Errorf("GET %s: wrong status: want %d, got %d: %s", test.path, test.status, w.Code, w.Body.String())
+		}
+	}
+
+	if len(undocumented) != 1 || undocumented[0] != "/api/v1/teams" {
+		t.Errorf("wrong undocumented requests: got %v", undocumented)
+	}
+}
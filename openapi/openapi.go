@@ -0,0 +1,450 @@
+// Package openapi loads OpenAPI 3 documents and validates requests and responses against them.
+//
+// Only JSON documents are supported. References are supported within the
+// document, e.g "#/components/schemas/User".
+//
+// Read more: https://spec.openapis.org/oas/v3.0.3
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Document is a OpenAPI 3 document.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []*Server            `json:"servers,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components,omitempty"`
+}
+
+// Info is the info object of a document.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server is a server object.
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem contains the operations of a path.
+type PathItem struct {
+	Parameters []*Parameter `json:"parameters,omitempty"`
+	Get        *Operation   `json:"get,omitempty"`
+	Put        *Operation   `json:"put,omitempty"`
+	Post       *Operation   `json:"post,omitempty"`
+	Delete     *Operation   `json:"delete,omitempty"`
+	Options    *Operation   `json:"options,omitempty"`
+	Head       *Operation   `json:"head,omitempty"`
+	Patch      *Operation   `json:"patch,omitempty"`
+	Trace      *Operation   `json:"trace,omitempty"`
+}
+
+// Operation is a operation object.
+type Operation struct {
+	OperationID string               `json:"operationId,omitempty"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter is a parameter object.
+type Parameter struct {
+	Ref      string      `json:"$ref,omitempty"`
+	Name     string      `json:"name,omitempty"`
+	In       string      `json:"in,omitempty"`
+	Required bool        `json:"required,omitempty"`
+	Schema   *Schema     `json:"schema,omitempty"`
+	Example  interface{} `json:"example,omitempty"`
+}
+
+// RequestBody is a request body object.
+type RequestBody struct {
+	Ref      string                `json:"$ref,omitempty"`
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content,omitempty"`
+}
+
+// Response is a response object.
+type Response struct {
+	Ref         string                `json:"$ref,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType is a media type object.
+type MediaType struct {
+	Schema  *Schema     `json:"schema,omitempty"`
+	Example interface{} `json:"example,omitempty"`
+}
+
+// Components contains the reusable objects of a document.
+type Components struct {
+	Schemas       map[string]*Schema      `json:"schemas,omitempty"`
+	Parameters    map[string]*Parameter   `json:"parameters,omitempty"`
+	RequestBodies map[string]*RequestBody `json:"requestBodies,omitempty"`
+	Responses     map[string]*Response    `json:"responses,omitempty"`
+}
+
+// Schema is a schema object.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Example              interface{}        `json:"example,omitempty"`
+
+	// never is true for the boolean schema false, that no value is valid against.
+	never bool
+}
+
+// UnmarshalJSON unmarshals a schema object or a boolean schema, e.g "additionalProperties": false.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	switch strings.TrimSpace(string(data)) {
+	case "true":
+		*s = Schema{}
+		return nil
+	case "false":
+		*s = Schema{never: true}
+		return nil
+	}
+
+	type schema Schema
+	return json.Unmarshal(data, (*schema)(s))
+}
+
+// Load loads a JSON OpenAPI 3 document.
+func Load(data []byte) (*Document, error) {
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("openapi: %v", err)
+	}
+
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("openapi: unsupported version %q", doc.OpenAPI)
+	}
+
+	return &doc, nil
+}
+
+// LoadFile loads a JSON OpenAPI 3 document from a file.
+func LoadFile(filename string) (*Document, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return Load(data)
+}
+
+// methods contains the methods of the operations of a path item.
+var methods = []string{"GET", "PUT", "POST", "DELETE", "OPTIONS", "HEAD", "PATCH", "TRACE"}
+
+// Operation returns the operation of the method, or nil if there's none.
+func (p *PathItem) Operation(method string) *Operation {
+	switch strings.ToUpper(method) {
+	case "GET":
+		return p.Get
+	case "PUT":
+		return p.Put
+	case "POST":
+		return p.Post
+	case "DELETE":
+		return p.Delete
+	case "OPTIONS":
+		return p.Options
+	case "HEAD":
+		return p.Head
+	case "PATCH":
+		return p.Patch
+	case "TRACE":
+		return p.Trace
+	}
+
+	return nil
+}
+
+// Route is a operation with the method and path template it's documented for.
+type Route struct {
+	Method    string
+	Path      string
+	Operation *Operation
+	PathItem  *PathItem
+}
+
+// Routes returns the operations of the document sorted by path and method.
+func (d *Document) Routes() []*Route {
+	paths := make([]string, 0, len(d.Paths))
+	for p := range d.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var routes []*Route
+	for _, p := range paths {
+		item := d.Paths[p]
+		for _, m := range methods {
+			if op := item.Operation(m); op != nil {
+				routes = append(routes, &Route{Method: m, Path: p, Operation: op, PathItem: item})
+			}
+		}
+	}
+
+	return routes
+}
+
+// Find returns the route that matches the method and request path, together with
+// the values of the path params. The base path of the servers, e.g "/api/v1" for
+// "https://example.com/api/v1", is removed from the request path before it's matched.
+// Paths with more literal segments is preferred.
+func (d *Document) Find(method, path string) (*Route, map[string]string) {
+	for _, base := range d.BasePaths() {
+		if p, ok := trimBasePath(path, base); ok {
+			if route, params := d.find(method, p); route != nil {
+				return route, params
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// Lookup returns the route that is documented for the method and router path, e.g
+// "/api/v1/users/:id", or nil if it's not documented. The base path of the servers
+// is removed from the router path before it's converted to a path template.
+func (d *Document) Lookup(method, path string) *Route {
+	for _, base := range d.BasePaths() {
+		p, ok := trimBasePath(path, base)
+		if !ok {
+			continue
+		}
+
+		tmpl := PathTemplate(p)
+		if item := d.Paths[tmpl]; item != nil {
+			if op := item.Operation(method); op != nil {
+				return &Route{Method: strings.ToUpper(method), Path: tmpl, Operation: op, PathItem: item}
+			}
+		}
+	}
+
+	return nil
+}
+
+// BasePaths returns the paths of the server URLs without trailing slashes, e.g "/api/v1"
+// for "https://example.com/api/v1/". A empty base path is returned if there is no servers.
+func (d *Document) BasePaths() []string {
+	var bases []string
+	seen := make(map[string]bool)
+
+	for _, server := range d.Servers {
+		u, err := url.Parse(server.URL)
+		if err != nil {
+			continue
+		}
+
+		base := strings.TrimSuffix(u.Path, "/")
+		if !seen[base] {
+			seen[base] = true
+			bases = append(bases, base)
+		}
+	}
+
+	if len(bases) == 0 {
+		bases = []string{""}
+	}
+
+	return bases
+}
+
+// trimBasePath removes the base path from the path, it returns false if the path is not below the base path.
+func trimBasePath(path, base string) (string, bool) {
+	if base == "" {
+		return path, true
+	}
+
+	if path == base {
+		return "/", true
+	}
+
+	if !strings.HasPrefix(path, base+"/") {
+		return "", false
+	}
+
+	return path[len(base):], true
+}
+
+// find returns the route that matches the method and path relative to the base path.
+func (d *Document) find(method, path string) (*Route, map[string]string) {
+	var best *Route
+	var bestParams map[string]string
+	bestLiterals := -1
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	for tmpl, item := range d.Paths {
+		op := item.Operation(method)
+		if op == nil {
+			continue
+		}
+
+		parts := strings.Split(strings.Trim(tmpl, "/"), "/")
+		if len(parts) != len(segments) {
+			continue
+		}
+
+		params := make(map[string]string)
+		literals := 0
+		match := true
+
+		for i, part := range parts {
+			if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+				if segments[i] == "" {
+					match = false
+					break
+				}
+				params[part[1:len(part)-1]] = segments[i]
+				continue
+			}
+
+			if part != segments[i] {
+				match = false
+				break
+			}
+			literals++
+		}
+
+		if match && (literals > bestLiterals || literals == bestLiterals && tmpl < best.Path) {
+			best = &Route{Method: strings.ToUpper(method), Path: tmpl, Operation: op, PathItem: item}
+			bestParams = params
+			bestLiterals = literals
+		}
+	}
+
+	return best, bestParams
+}
+
+// PathTemplate converts a router path, e.g "/users/:id" or "/files/*path",
+// to a OpenAPI path template, e.g "/users/{id}" or "/files/{path}".
+// Constraints of path params, e.g ":id<int>", is removed.
+func PathTemplate(path string) string {
+	parts := strings.Split(path, "/")
+
+	for i, part := range parts {
+		if len(part) > 1 && (part[0] == ':' || part[0] == '*') {
+			name := part[1:]
+			if j := strings.IndexByte(name, '<'); j >= 0 {
+				name = name[:j]
+			}
+			parts[i] = "{" + name + "}"
+		}
+	}
+
+	return strings.Join(parts, "/")
+}
+
+// Parameters returns the parameters of the operation merged with the parameters of the path item,
+// with references resolved.
+func (d *Document) Parameters(r *Route) []*Parameter {
+	var params []*Parameter
+	seen := make(map[string]bool)
+
+	for _, list := range [][]*Parameter{r.Operation.Parameters, r.PathItem.Parameters} {
+		for _, p := range list {
+			p = d.parameter(p)
+			if p == nil || seen[p.In+" "+p.Name] {
+				continue
+			}
+
+			seen[p.In+" "+p.Name] = true
+			params = append(params, p)
+		}
+	}
+
+	return params
+}
+
+// refName returns the name of a component reference, e.g "User" for "#/components/schemas/User".
+func refName(ref, kind string) string {
+	prefix := "#/components/" + kind + "/"
+	if !strings.HasPrefix(ref, prefix) {
+		return ""
+	}
+
+	return ref[len(prefix):]
+}
+
+// parameter resolves a parameter reference.
+func (d *Document) parameter(p *Parameter) *Parameter {
+	for i := 0; p != nil && p.Ref != "" && i < 32; i++ {
+		p = d.Components.Parameters[refName(p.Ref, "parameters")]
+	}
+
+	return p
+}
+
+// RequestBody resolves a request body reference. Nil is returned when the reference can't be resolved.
+func (d *Document) RequestBody(b *RequestBody) *RequestBody {
+	for i := 0; b != nil && b.Ref != "" && i < 32; i++ {
+		b = d.Components.RequestBodies[refName(b.Ref, "requestBodies")]
+	}
+
+	return b
+}
+
+// Response resolves a response reference. Nil is returned when the reference can't be resolved.
+func (d *Document) Response(r *Response) *Response {
+	for i := 0; r != nil && r.Ref != "" && i < 32; i++ {
+		r = d.Components.Responses[refName(r.Ref, "responses")]
+	}
+
+	return r
+}
+
+// Schema resolves a schema reference. Nil is returned when the reference can't be resolved.
+func (d *Document) Schema(s *Schema) *Schema {
+	for i := 0; s != nil && s.Ref != "" && i < 32; i++ {
+		s = d.Components.Schemas[refName(s.Ref, "schemas")]
+	}
+
+	return s
+}
+
+// sortedKeys returns the keys of the map sorted.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,221 @@
+package openapi
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const testDocument = `{
+	"openapi": "3.0.3",
+	"info": {"title": "Users", "version": "1.0.0"},
+	"paths": {
+		"/users": {
+			"get": {
+				"parameters": [
+					{"name": "limit", "in": "query", "schema": {"type": "integer", "minimum": 1, "maximum": 100}},
+					{"name": "tags", "in": "query", "schema": {"type": "array", "items": {"type": "string", "enum": ["a", "b"]}}}
+				],
+				"responses": {"200": {"description": "ok"}}
+			},
+			"post": {
+				"requestBody": {"$ref": "#/components/requestBodies/User"},
+				"responses": {"201": {"$ref": "#/components/responses/User"}}
+			}
+		},
+		"/users/{id}": {
+			"parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string", "format": "uuid"}}],
+			"get": {"responses": {"2XX": {"$ref": "#/components/responses/User"}, "default": {"description": "error"}}}
+		},
+		"/users/me": {
+			"get": {"responses": {"200": {"$ref": "#/components/responses/User"}}}
+		}
+	},
+	"components": {
+		"schemas": {
+			"User": {
+				"type": "object",
+				"required": ["name"],
+				"additionalProperties": false,
+				"properties": {
+					"id": {"type": "string", "format": "uuid"},
+					"name": {"type": "string", "minLength": 1},
+					"email": {"type": "string", "format": "email", "nullable": true},
+					"roles": {"type": "array", "maxItems": 2, "items": {"type": "string"}}
+				}
+			}
+		},
+		"requestBodies": {
+			"User": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/User"}}}}
+		},
+		"responses": {
+			"User": {"description": "user", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/User"}}}}
+		}
+	}
+}`
+
+func loadTestDocument(t *testing.T) *Document {
+	doc, err := Load([]byte(testDocument))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return doc
+}
+
+func TestFind(t *testing.T) {
+	doc := loadTestDocument(t)
+
+	tests := []struct {
+		method string
+		path   string
+		tmpl   string
+		id     string
+	}{
+		{"GET", "/users", "/users", ""},
+		{"GET", "/users/me", "/users/me", ""},
+		{"GET", "/users/123", "/users/{id}", "123"},
+		{"DELETE", "/users/123", "", ""},
+		{"GET", "/teams", "", ""},
+	}
+
+	for _, test := range tests {
+		route, params := doc.Find(test.method, test.path)
+
+		if test.tmpl == "" {
+			if route != nil {
+				t.Errorf("%s %s: unexpected route %s", test.method, test.path, route.Path)
+			}
+			continue
+		}
+
+		if route == nil || route.Path != test.tmpl {
+			t.Errorf("%s %s: wrong route: want %s, got %v", test.method, test.path, test.tmpl, route)
+			continue
+		}
+
+		if params["id"] != test.id {
+			t.Errorf("%s %s: wrong id param: want %q, got %q", test.method, test.path, test.id, params["id"])
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	doc := loadTestDocument(t)
+	doc.Servers = []*Server{{URL: "https://example.com/api/v1"}, {URL: "/"}}
+
+	tests := []struct {
+		method string
+		path   string
+		tmpl   string
+	}{
+		{"GET", "/api/v1/users", "/users"},
+		{"GET", "/api/v1/users/:id<int>", "/users/{id}"},
+		{"GET", "/users/me", "/users/me"},
+		{"POST", "/api/v1/users/:id", ""},
+		{"GET", "/api/v2/users", ""},
+	}
+
+	for _, test := range tests {
+		route := doc.Lookup(test.method, test.path)
+
+		if test.tmpl == "" {
+			if route != nil {
+				t.Errorf("%s %s: unexpected route %s", test.method, test.path, route.Path)
+			}
+			continue
+		}
+
+		if route == nil || route.Path != test.tmpl {
+			t.Errorf("%s %s: wrong route: want %s, got %v", test.method, test.path, test.tmpl, route)
+		}
+	}
+
+	if route, _ := doc.Find("GET", "/api/v1/users/me"); route == nil || route.Path != "/users/me" {
+		t.Errorf("wrong route for base path: got %v", route)
+	}
+}
+
+func TestPathTemplate(t *testing.T) {
+	tests := map[string]string{
+		"/users":              "/users",
+		"/users/:id":          "/users/{id}",
+		"/users/:id<int>/x":   "/users/{id}/x",
+		"/files/*path":        "/files/{path}",
+		"/:org/repos/:repo/*": "/{org}/repos/{repo}/*",
+	}
+
+	for in, want := range tests {
+		if got := PathTemplate(in); got != want {
+			t.Errorf("%s: want %s, got %s", in, want, got)
+		}
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	doc := loadTestDocument(t)
+
+	tests := []struct {
+		method     string
+		path       string
+		body       string
+		violations []string
+	}{
+		{"GET", "/users?limit=10&tags=a,b", "", nil},
+		{"GET", "/users?limit=0&tags=c", "", []string{"query limit: must be at least 1", "query tags[0]: must be one of [a b]"}},
+		{"GET", "/users?limit=ten", "", []string{"query limit: must be a integer"}},
+		{"GET", "/users/123", "", []string{"path id: must be a UUID"}},
+		{"POST", "/users", `{"name":"fredrik","email":null}`, nil},
+		{"POST", "/users", "", []string{"body: is required"}},
+		{"POST", "/users", `{"name":"","roles":["a","b","c"],"admin":true}`, []string{
+			"body admin: is not allowed",
+			"body name: must be at least 1 characters",
+			"body roles: must have at most 2 items",
+		}},
+		{"POST", "/users", `{"email":"fredrik"}`, []string{"body name: is required", "body email: must be a email address"}},
+	}
+
+	for _, test := range tests {
+		r, _ := http.NewRequest(test.method, test.path, nil)
+		r.Header.Set("Content-Type", "application/json")
+
+		route, params := doc.Find(r.Method, r.URL.Path)
+		if route == nil {
+			t.Fatalf("%s %s: no route", test.method, test.path)
+		}
+
+		var got []string
+		for _, v := range doc.ValidateRequest(r, route, params, []byte(test.body)) {
+			got = append(got, v.String())
+		}
+
+		if strings.Join(got, "\n") != strings.Join(test.violations, "\n") {
+			t.Errorf("%s %s %s: wrong violations:\nwant %q\ngot  %q", test.method, test.path, test.body, test.violations, got)
+		}
+	}
+}
+
+func TestValidateResponse(t *testing.T) {
+	doc := loadTestDocument(t)
+	header := http.Header{"Content-Type": []string{"application/json"}}
+
+	route, _ := doc.Find("POST", "/users")
+
+	if vs := doc.ValidateResponse(route, http.StatusCreated, header, []byte(`{"name":"fredrik"}`)); len(vs) != 0 {
+		t.Errorf("unexpected violations: %v", vs)
+	}
+
+	if vs := doc.ValidateResponse(route, http.StatusOK, header, nil); len(vs) != 1 || vs[0].Message != "undocumented status code 200" {
+		t.Errorf("wrong violations: %v", vs)
+	}
+
+	if vs := doc.ValidateResponse(route, http.StatusCreated, header, []byte(`{"id":1}`)); len(vs) != 2 {
+		t.Errorf("wrong violations: %v", vs)
+	}
+
+	route, _ = doc.Find("GET", "/users/123")
+
+	if vs := doc.ValidateResponse(route, http.StatusNotFound, header, []byte(`{"error":"Not Found"}`)); len(vs) != 0 {
+		t.Errorf("unexpected violations for default response: %v", vs)
+	}
+}
@@ -0,0 +1,419 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Violation is a violation of the document by a request or a response.
+type Violation struct {
+	// In is where the violation is, e.g "path", "query", "header" or "body".
+	In string `json:"in"`
+
+	// Field is the name of the param or the path to the field in the body, e.g "user.name".
+	Field string `json:"field,omitempty"`
+
+	// Message describes the violation.
+	Message string `json:"message"`
+
+	// malformed is true when the body is missing, has a unsupported content type or can't be decoded.
+	malformed bool
+}
+
+// String returns the violation as a string, e.g "query limit: must be a integer".
+func (v Violation) String() string {
+	if v.Field == "" {
+		return v.In + ": " + v.Message
+	}
+
+	return v.In + " " + v.Field + ": " + v.Message
+}
+
+// ValidateRequest validates the request params and body against the operation of the route.
+// The body is the read request body, the request body is not read.
+func (d *Document) ValidateRequest(r *http.Request, route *Route, pathParams map[string]string, body []byte) []Violation {
+	var vs []Violation
+
+	for _, p := range d.Parameters(route) {
+		var values []string
+
+		switch p.In {
+		case "path":
+			if v, ok := pathParams[p.Name]; ok {
+				values = []string{v}
+			}
+		case "query":
+			values = r.URL.Query()[p.Name]
+		case "header":
+			values = r.Header[http.CanonicalHeaderKey(p.Name)]
+		case "cookie":
+			if c, err := r.Cookie(p.Name); err == nil {
+				values = []string{c.Value}
+			}
+		default:
+			continue
+		}
+
+		if len(values) == 0 {
+			if p.Required || p.In == "path" {
+				vs = append(vs, Violation{In: p.In, Field: p.Name, Message: "is required"})
+			}
+			continue
+		}
+
+		vs = append(vs, d.validateParam(p, values)...)
+	}
+
+	rb := d.RequestBody(route.Operation.RequestBody)
+	if rb == nil {
+		return vs
+	}
+
+	if len(body) == 0 {
+		if rb.Required {
+			vs = append(vs, Violation{In: "body", Message: "is required", malformed: true})
+		}
+		return vs
+	}
+
+	mt, typ := findMediaType(rb.Content, r.Header.Get("Content-Type"))
+	if mt == nil {
+		return append(vs, Violation{In: "body", Message: fmt.Sprintf("unsupported content type %q", r.Header.Get("Content-Type")), malformed: true})
+	}
+
+	return append(vs, d.validateBody(typ, mt, body)...)
+}
+
+// ValidateResponse validates the status code, content type and body of a response against the operation of the route.
+func (d *Document) ValidateResponse(route *Route, status int, header http.Header, body []byte) []Violation {
+	res := d.findResponse(route.Operation, status)
+	if res == nil {
+		return []Violation{{In: "status", Message: fmt.Sprintf("undocumented status code %d", status)}}
+	}
+
+	if len(body) == 0 || len(res.Content) == 0 {
+		return nil
+	}
+
+	mt, typ := findMediaType(res.Content, header.Get("Content-Type"))
+	if mt == nil {
+		return []Violation{{In: "header", Field: "Content-Type", Message: fmt.Sprintf("undocumented content type %q", header.Get("Content-Type"))}}
+	}
+
+	return d.validateBody(typ, mt, body)
+}
+
+// findResponse returns the response of the status code, a range like "2XX" or the default response.
+func (d *Document) findResponse(op *Operation, status int) *Response {
+	code := strconv.Itoa(status)
+
+	for _, key := range []string{code, code[:1] + "XX", code[:1] + "xx", "default"} {
+		if res, ok := op.Responses[key]; ok {
+			return d.Response(res)
+		}
+	}
+
+	return nil
+}
+
+// findMediaType returns the media type object that matches the content type header.
+func findMediaType(content map[string]*MediaType, contentType string) (*MediaType, string) {
+	typ, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		typ = ""
+	}
+
+	if mt, ok := content[typ]; ok {
+		return mt, typ
+	}
+
+	if i := strings.IndexByte(typ, '/'); i > 0 {
+		if mt, ok := content[typ[:i]+"/*"]; ok {
+			return mt, typ
+		}
+	}
+
+	if mt, ok := content["*/*"]; ok {
+		return mt, typ
+	}
+
+	return nil, typ
+}
+
+// isJSON reports whether the media type is JSON, e.g "application/json" or "application/problem+json".
+func isJSON(typ string) bool {
+	return typ == "application/json" || strings.HasSuffix(typ, "+json")
+}
+
+// validateBody validates a JSON body against the schema of the media type, other bodies is not validated.
+func (d *Document) validateBody(typ string, mt *MediaType, body []byte) []Violation {
+	if !isJSON(typ) || mt.Schema == nil {
+		return nil
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return []Violation{{In: "body", Message: "invalid JSON: " + err.Error(), malformed: true}}
+	}
+
+	return d.Validate(mt.Schema, v, "body")
+}
+
+// validateParam converts the param values to the type of the schema and validates them.
+func (d *Document) validateParam(p *Parameter, values []string) []Violation {
+	schema := d.Schema(p.Schema)
+	if schema == nil {
+		return nil
+	}
+
+	var v interface{}
+
+	if schema.Type == "array" {
+		if len(values) == 1 {
+			values = strings.Split(values[0], ",")
+		}
+
+		items := make([]interface{}, len(values))
+		for i, s := range values {
+			items[i] = convertParam(d.Schema(schema.Items), s)
+		}
+		v = items
+	} else {
+		v = convertParam(schema, values[0])
+	}
+
+	vs := d.Validate(schema, v, p.In)
+	for i := range vs {
+		vs[i].Field = p.Name + vs[i].Field
+	}
+
+	return vs
+}
+
+// convertParam converts a param value to the type of the schema, the string is returned if it can't be converted.
+func convertParam(schema *Schema, s string) interface{} {
+	if schema == nil {
+		return s
+	}
+
+	switch schema.Type {
+	case "integer", "number":
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	}
+
+	return s
+}
+
+// Validate validates a value decoded from JSON against the schema. The location is
+// used as the In of the violations, and the path to the invalid field as the Field.
+func (d *Document) Validate(schema *Schema, v interface{}, in string) []Violation {
+	var vs []Violation
+	d.validate(schema, v, in, "", &vs)
+	return vs
+}
+
+// validate validates the value and appends the violations.
+func (d *Document) validate(schema *Schema, v interface{}, in, field string, vs *[]Violation) {
+	schema = d.Schema(schema)
+	if schema == nil {
+		return
+	}
+
+	add := func(format string, args ...interface{}) {
+		*vs = append(*vs, Violation{In: in, Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if schema.never {
+		add("is not allowed")
+		return
+	}
+
+	for _, s := range schema.AllOf {
+		d.validate(s, v, in, field, vs)
+	}
+
+	if len(schema.AnyOf) > 0 && d.matches(schema.AnyOf, v) == 0 {
+		add("must match any of the schemas")
+	}
+
+	if len(schema.OneOf) > 0 && d.matches(schema.OneOf, v) != 1 {
+		add("must match exactly one of the schemas")
+	}
+
+	if v == nil {
+		if !schema.Nullable && schema.Type != "" {
+			add("must not be null")
+		}
+		return
+	}
+
+	if len(schema.Enum) > 0 {
+		found := false
+		for _, e := range schema.Enum {
+			if reflect.DeepEqual(e, v) || fmt.Sprint(e) == fmt.Sprint(v) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			add("must be one of %v", schema.Enum)
+		}
+	}
+
+	switch schema.Type {
+	case "object":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			add("must be a object")
+			return
+		}
+		d.validateObject(schema, obj, in, field, vs)
+	case "array":
+		arr, ok := v.([]interface{})
+		if !ok {
+			add("must be a array")
+			return
+		}
+
+		if schema.MinItems != nil && len(arr) < *schema.MinItems {
+			add("must have at least %d items", *schema.MinItems)
+		}
+
+		if schema.MaxItems != nil && len(arr) > *schema.MaxItems {
+			add("must have at most %d items", *schema.MaxItems)
+		}
+
+		for i, item := range arr {
+			d.validate(schema.Items, item, in, fmt.Sprintf("%s[%d]", field, i), vs)
+		}
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			add("must be a string")
+			return
+		}
+
+		if schema.MinLength != nil && utf8.RuneCountInString(s) < *schema.MinLength {
+			add("must be at least %d characters", *schema.MinLength)
+		}
+
+		if schema.MaxLength != nil && utf8.RuneCountInString(s) > *schema.MaxLength {
+			add("must be at most %d characters", *schema.MaxLength)
+		}
+
+		if schema.Pattern != "" {
+			if re, err := regexp.Compile(schema.Pattern); err == nil && !re.MatchString(s) {
+				add("must match %s", schema.Pattern)
+			}
+		}
+
+		if msg := validateFormat(schema.Format, s); msg != "" {
+			add("%s", msg)
+		}
+	case "integer", "number":
+		n, ok := v.(float64)
+		if !ok {
+			add("must be a %s", schema.Type)
+			return
+		}
+
+		if schema.Type == "integer" && n != float64(int64(n)) {
+			add("must be a integer")
+			return
+		}
+
+		if schema.Minimum != nil && n < *schema.Minimum {
+			add("must be at least %v", *schema.Minimum)
+		}
+
+		if schema.Maximum != nil && n > *schema.Maximum {
+			add("must be at most %v", *schema.Maximum)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			add("must be a boolean")
+		}
+	case "":
+		if obj, ok := v.(map[string]interface{}); ok && (schema.Properties != nil || len(schema.Required) > 0) {
+			d.validateObject(schema, obj, in, field, vs)
+		}
+	}
+}
+
+// validateObject validates the properties of a object.
+func (d *Document) validateObject(schema *Schema, obj map[string]interface{}, in, field string, vs *[]Violation) {
+	prefix := field
+	if prefix != "" || in != "body" {
+		prefix += "."
+	}
+
+	for _, name := range schema.Required {
+		if _, ok := obj[name]; !ok {
+			*vs = append(*vs, Violation{In: in, Field: prefix + name, Message: "is required"})
+		}
+	}
+
+	for _, name := range sortedKeys(obj) {
+		if s, ok := schema.Properties[name]; ok {
+			d.validate(s, obj[name], in, prefix+name, vs)
+		} else if schema.AdditionalProperties != nil {
+			d.validate(schema.AdditionalProperties, obj[name], in, prefix+name, vs)
+		}
+	}
+}
+
+// matches returns the number of schemas that the value is valid against.
+func (d *Document) matches(schemas []*Schema, v interface{}) int {
+	n := 0
+	for _, s := range schemas {
+		var vs []Violation
+		d.validate(s, v, "", "", &vs)
+		if len(vs) == 0 {
+			n++
+		}
+	}
+
+	return n
+}
+
+// uuidPattern matches a UUID.
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// validateFormat validates the string against the format and returns a message if it's invalid.
+func validateFormat(format, s string) string {
+	switch format {
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			return "must be a RFC 3339 date-time"
+		}
+	case "date":
+		if _, err := time.Parse("2006-01-02", s); err != nil {
+			return "must be a date"
+		}
+	case "uuid":
+		if !uuidPattern.MatchString(s) {
+			return "must be a UUID"
+		}
+	case "email":
+		if i := strings.IndexByte(s, '@'); i < 1 || i == len(s)-1 {
+			return "must be a email address"
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,139 @@
+package httpapi
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// UUID represents a UUID.
+type UUID [16]byte
+
+// ParseUUID parses a UUID in its canonical form, e.g "123e4567-e89b-12d3-a456-426614174000".
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+
+	if !uuidRegexp.MatchString(s) {
+		return u, fmt.Errorf("invalid UUID %q", s)
+	}
+
+	b := []byte(s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:36])
+	if _, err := hex.Decode(u[:], b); err != nil {
+		return u, fmt.Errorf("invalid UUID %q", s)
+	}
+
+	return u, nil
+}
+
+// String returns the UUID in its canonical form.
+func (u UUID) String() string {
+	s := hex.EncodeToString(u[:])
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:32]
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (u *UUID) UnmarshalText(b []byte) error {
+	v, err := ParseUUID(string(b))
+	if err != nil {
+		return err
+	}
+
+	*u = v
+	return nil
+}
+
+// paramError returns a error for a invalid param that responds with 400 Bad Request.
+func paramError(name, value, typ string) error {
+	return Errorf(http.StatusBadRequest, "invalid value %q for param %q: must be %s", value, name, typ)
+}
+
+// Int returns the value of the param with the given name as a int.
+// The error responds with 400 Bad Request when returned from a handle.
+func (ps Params) Int(name string) (int, error) {
+	v := ps.ByName(name)
+
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, paramError(name, v, "a integer")
+	}
+
+	return i, nil
+}
+
+// Int64 returns the value of the param with the given name as a int64.
+// The error responds with 400 Bad Request when returned from a handle.
+func (ps Params) Int64(name string) (int64, error) {
+	v := ps.ByName(name)
+
+	i, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, paramError(name, v, "a integer")
+	}
+
+	return i, nil
+}
+
+// UUID returns the value of the param with the given name as a UUID.
+// The error responds with 400 Bad Request when returned from a handle.
+func (ps Params) UUID(name string) (UUID, error) {
+	v := ps.ByName(name)
+
+	u, err := ParseUUID(v)
+	if err != nil {
+		return u, paramError(name, v, "a UUID")
+	}
+
+	return u, nil
+}
+
+// Bool returns the value of the param with the given name as a bool.
+// The error responds with 400 Bad Request when returned from a handle.
+func (ps Params) Bool(name string) (bool, error) {
+	v := ps.ByName(name)
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, paramError(name, v, "a boolean")
+	}
+
+	return b, nil
+}
+
+// Time returns the value of the param with the given name as a time.
+// The value is parsed with the given layout or time.RFC3339 if no layout is given.
+// The error responds with 400 Bad Request when returned from a handle.
+func (ps Params) Time(name string, layout ...string) (time.Time, error) {
+	v := ps.ByName(name)
+
+	l := time.RFC3339
+	if len(layout) > 0 {
+		l = layout[0]
+	}
+
+	t, err := time.Parse(l, v)
+	if err != nil {
+		return t, paramError(name, v, "a time in the format "+l)
+	}
+
+	return t, nil
+}
+
+// Duration returns the value of the param with the given name as a duration, e.g "1h30m".
+// The error responds with 400 Bad Request when returned from a handle.
+func (ps Params) Duration(name string) (time.Duration, error) {
+	v := ps.ByName(name)
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, paramError(name, v, "a duration")
+	}
+
+	return d, nil
+}
@@ -0,0 +1,51 @@
+package httpapi
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParamsAccessors(t *testing.T) {
+	ps := Params{
+		Param{Key: "id", Value: "42"},
+		Param{Key: "uuid", Value: "123e4567-e89b-12d3-a456-426614174000"},
+		Param{Key: "active", Value: "true"},
+		Param{Key: "since", Value: "2020-03-04T10:00:00Z"},
+		Param{Key: "ttl", Value: "1h30m"},
+		Param{Key: "name", Value: "fredrik"},
+	}
+
+	if i, err := ps.Int("id"); err != nil || i != 42 {
+		t.Errorf("Int failed: %v %v", i, err)
+	}
+
+	if i, err := ps.Int64("id"); err != nil || i != 42 {
+		t.Errorf("Int64 failed: %v %v", i, err)
+	}
+
+	if u, err := ps.UUID("uuid"); err != nil || u.String() != "123e4567-e89b-12d3-a456-426614174000" {
+		t.Errorf("UUID failed: %v %v", u, err)
+	}
+
+	if b, err := ps.Bool("active"); err != nil || !b {
+		t.Errorf("Bool failed: %v %v", b, err)
+	}
+
+	if v, err := ps.Time("since"); err != nil || !v.Equal(time.Date(2020, 3, 4, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("Time failed: %v %v", v, err)
+	}
+
+	if d, err := ps.Duration("ttl"); err != nil || d != 90*time.Minute {
+		t.Errorf("Duration failed: %v %v", d, err)
+	}
+
+	_, err := ps.Int("name")
+	if err == nil || StatusCode(err) != http.StatusBadRequest {
+		t.Errorf("expected bad request error: got %v", err)
+	}
+
+	if _, err := ps.UUID("name"); err == nil {
+		t.Error("expected UUID error")
+	}
+}
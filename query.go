@@ -0,0 +1,257 @@
+package httpapi
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// DecodeQuery decodes query values into the struct that v points to.
+//
+// Fields are decoded from the key in the field's `query` tag or from the
+// field name in lower case, a tag of "-" skips the field. A `default` tag
+// is used when the key is missing, comma separated for slices.
+//
+// Slices are decoded from repeated keys, "tag=a&tag=b" or "tag[]=a&tag[]=b".
+// Structs and maps are decoded from nested keys, e.g "filter[status]=open".
+// Types that implements encoding.TextUnmarshaler, e.g time.Time and UUID,
+// are decoded with it.
+//
+// Errors for invalid values responds with 400 Bad Request when returned from a handle.
+func DecodeQuery(values url.Values, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("httpapi: DecodeQuery requires a non-nil pointer to a struct")
+	}
+
+	return decodeQueryStruct(values, "", rv.Elem())
+}
+
+// queryKey returns the key of a nested name.
+func queryKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+
+	return prefix + "[" + name + "]"
+}
+
+// decodeQueryStruct decodes query values into the fields of a struct.
+func decodeQueryStruct(values url.Values, prefix string, v reflect.Value) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		fv := v.Field(i)
+		name := f.Tag.Get("query")
+
+		if name == "-" {
+			continue
+		}
+
+		// Embedded structs are decoded as if their fields were in the parent struct.
+		if f.Anonymous && name == "" && fv.Kind() == reflect.Struct {
+			if err := decodeQueryStruct(values, prefix, fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !fv.CanSet() {
+			continue
+		}
+
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+
+		if err := decodeQueryValue(values, queryKey(prefix, name), fv, f.Tag.Get("default")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decodeQueryValue decodes the query values of a key into a value.
+func decodeQueryValue(values url.Values, key string, v reflect.Value, def string) error {
+	if isQueryScalar(v.Type()) {
+		vals := values[key]
+		if len(vals) == 0 {
+			if def == "" {
+				return nil
+			}
+			vals = []string{def}
+		}
+
+		return setQueryScalar(key, v, vals[0])
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !hasQueryKey(values, key) && def == "" {
+			return nil
+		}
+
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+
+		return decodeQueryValue(values, key, v.Elem(), def)
+	case reflect.Slice:
+		vals := append(append([]string(nil), values[key]...), values[key+"[]"]...)
+		if len(vals) == 0 {
+			if def == "" {
+				return nil
+			}
+			vals = strings.Split(def, ",")
+		}
+
+		s := reflect.MakeSlice(v.Type(), len(vals), len(vals))
+		for i, val := range vals {
+			if err := setQueryScalar(key, s.Index(i), val); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+
+		return nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("httpapi: unsupported map key type %s for query param %q", v.Type().Key(), key)
+		}
+
+		var keys []string
+		for k := range values {
+			if strings.HasPrefix(k, key+"[") && strings.HasSuffix(k, "]") && !strings.Contains(k[len(key)+1:len(k)-1], "[") {
+				keys = append(keys, k)
+			}
+		}
+
+		if len(keys) == 0 {
+			return nil
+		}
+		sort.Strings(keys)
+
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+
+		for _, k := range keys {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := decodeQueryValue(values, k, elem, ""); err != nil {
+				return err
+			}
+			v.SetMapIndex(reflect.ValueOf(k[len(key)+1:len(k)-1]).Convert(v.Type().Key()), elem)
+		}
+
+		return nil
+	case reflect.Struct:
+		return decodeQueryStruct(values, key, v)
+	}
+
+	return fmt.Errorf("httpapi: unsupported type %s for query param %q", v.Type(), key)
+}
+
+// hasQueryKey reports whether the key or any nested key exists in the query values.
+func hasQueryKey(values url.Values, key string) bool {
+	for k := range values {
+		if k == key || strings.HasPrefix(k, key+"[") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isQueryScalar reports whether the type is decoded from a single query value.
+func isQueryScalar(t reflect.Type) bool {
+	if reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+
+	return false
+}
+
+// setQueryScalar sets the value from a single query value.
+func setQueryScalar(key string, v reflect.Value, s string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	invalid := func(err error) error {
+		return Errorf(http.StatusBadRequest, "invalid value %q for query param %q: %v", s, key, err)
+	}
+
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		if err := u.UnmarshalText([]byte(s)); err != nil {
+			return invalid(err)
+		}
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return invalid(errors.New("must be a boolean"))
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == durationType {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return invalid(errors.New("must be a duration"))
+			}
+			v.SetInt(int64(d))
+			return nil
+		}
+
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return invalid(errors.New("must be a integer"))
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return invalid(errors.New("must be a positive integer"))
+		}
+		v.SetUint(i)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return invalid(errors.New("must be a number"))
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("httpapi: unsupported type %s for query param %q", v.Type(), key)
+	}
+
+	return nil
+}
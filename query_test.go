@@ -0,0 +1,100 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type queryPage struct {
+	Limit int `query:"limit" default:"20"`
+}
+
+type queryFilter struct {
+	Status string `query:"status"`
+	Owner  *UUID  `query:"owner"`
+}
+
+type queryTest struct {
+	queryPage
+	Search  string            `query:"q"`
+	Tags    []string          `query:"tag"`
+	IDs     []int             `query:"id" default:"1,2"`
+	Since   time.Time         `query:"since"`
+	TTL     time.Duration     `query:"ttl"`
+	Active  *bool             `query:"active"`
+	Filter  queryFilter       `query:"filter"`
+	Meta    map[string]string `query:"meta"`
+	Ignored string            `query:"-"`
+}
+
+func TestDecodeQuery(t *testing.T) {
+	values, _ := url.ParseQuery("q=gopher&tag=a&tag[]=b&since=2020-03-04T10:00:00Z&ttl=1m&active=false" +
+		"&filter[status]=open&filter[owner]=123e4567-e89b-12d3-a456-426614174000&meta[a]=1&meta[b]=2&Ignored=x")
+
+	var q queryTest
+	if err := DecodeQuery(values, &q); err != nil {
+		t.Fatal(err)
+	}
+
+	if q.Limit != 20 {
+		t.Errorf("wrong limit: want 20, got %d", q.Limit)
+	}
+
+	if q.Search != "gopher" {
+		t.Errorf("wrong search: want gopher, got %s", q.Search)
+	}
+
+	if !reflect.DeepEqual(q.Tags, []string{"a", "b"}) {
+		t.Errorf("wrong tags: got %v", q.Tags)
+	}
+
+	if !reflect.DeepEqual(q.IDs, []int{1, 2}) {
+		t.Errorf("wrong ids: got %v", q.IDs)
+	}
+
+	if !q.Since.Equal(time.Date(2020, 3, 4, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("wrong since: got %v", q.Since)
+	}
+
+	if q.TTL != time.Minute {
+		t.Errorf("wrong ttl: got %v", q.TTL)
+	}
+
+	if q.Active == nil || *q.Active {
+		t.Errorf("wrong active: got %v", q.Active)
+	}
+
+	if q.Filter.Status != "open" || q.Filter.Owner == nil || q.Filter.Owner.String() != "123e4567-e89b-12d3-a456-426614174000" {
+		t.Errorf("wrong filter: got %+v", q.Filter)
+	}
+
+	if !reflect.DeepEqual(q.Meta, map[string]string{"a": "1", "b": "2"}) {
+		t.Errorf("wrong meta: got %v", q.Meta)
+	}
+
+	if q.Ignored != "" {
+		t.Errorf("ignored field decoded: got %s", q.Ignored)
+	}
+}
+
+func TestDecodeQueryErrors(t *testing.T) {
+	values, _ := url.ParseQuery("limit=abc")
+
+	var q queryTest
+	err := DecodeQuery(values, &q)
+	if err == nil || StatusCode(err) != http.StatusBadRequest {
+		t.Fatalf("expected bad request error: got %v", err)
+	}
+
+	want := `invalid value "abc" for query param "limit": must be a integer`
+	if err.Error() != want {
+		t.Errorf("wrong error: want %s, got %s", want, err.Error())
+	}
+
+	if err := DecodeQuery(values, q); err == nil {
+		t.Error("expected error for non-pointer")
+	}
+}
@@ -0,0 +1,127 @@
+package httpapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var (
+	errorType            = reflect.TypeOf((*error)(nil)).Elem()
+	contextType          = reflect.TypeOf((*context.Context)(nil)).Elem()
+	httpapiContextType   = reflect.TypeOf((*Context)(nil))
+	requestType          = reflect.TypeOf((*http.Request)(nil))
+	responseWriterType   = reflect.TypeOf((*http.ResponseWriter)(nil)).Elem()
+	paramsType           = reflect.TypeOf(Params(nil))
+	httprouterParamsType = reflect.TypeOf(httprouter.Params(nil))
+)
+
+// reflectHandle returns a handle that calls a function with any of the arguments
+// context.Context, *Context, *http.Request, http.ResponseWriter, Params or
+// httprouter.Params, that returns a value of any type and a error or just a error.
+// One argument may be a struct, a pointer to a struct, a map or a slice that the
+// JSON request body is decoded into, see Context.Bind.
+// The returned values is responded with the response handle of the router.
+func (r *Router) reflectHandle(handle interface{}) (Handle, error) {
+	fn := reflect.ValueOf(handle)
+	if handle == nil || fn.Kind() != reflect.Func || fn.IsNil() {
+		return nil, fmt.Errorf("unsupported handle type %T", handle)
+	}
+
+	t := fn.Type()
+	body := -1
+
+	for i := 0; i < t.NumIn(); i++ {
+		switch t.In(i) {
+		case contextType, httpapiContextType, requestType, responseWriterType, paramsType, httprouterParamsType:
+		default:
+			if !isBodyType(t.In(i)) {
+				return nil, fmt.Errorf("unsupported handle type %s: argument %d has unsupported type %s", t, i+1, t.In(i))
+			}
+			if body != -1 {
+				return nil, fmt.Errorf("unsupported handle type %s: only one argument can be decoded from the request body", t)
+			}
+			body = i
+		}
+	}
+
+	if t.IsVariadic() {
+		return nil, fmt.Errorf("unsupported handle type %s: variadic arguments is not supported", t)
+	}
+
+	if n := t.NumOut(); n < 1 || n > 2 || t.Out(n-1) != errorType {
+		return nil, fmt.Errorf("unsupported handle type %s: must return a error or a value and a error", t)
+	}
+
+	respond := r.ResponseHandle
+
+	return func(w http.ResponseWriter, req *http.Request, ps Params) {
+		respond(func(req *http.Request, ps Params) (interface{}, interface{}) {
+			args := make([]reflect.Value, t.NumIn())
+
+			for i := range args {
+				switch t.In(i) {
+				case contextType:
+					args[i] = reflect.ValueOf(req.Context())
+				case httpapiContextType:
+					args[i] = reflect.ValueOf(NewContext(w, req, ps))
+				case requestType:
+					args[i] = reflect.ValueOf(req)
+				case responseWriterType:
+					args[i] = reflect.ValueOf(&w).Elem()
+				case paramsType:
+					args[i] = reflect.ValueOf(ps)
+				case httprouterParamsType:
+					args[i] = reflect.ValueOf(httprouter.Params(ps))
+				default:
+					v, err := bindBody(NewContext(w, req, ps), t.In(i))
+					if err != nil {
+						return nil, err
+					}
+					args[i] = v
+				}
+			}
+
+			out := fn.Call(args)
+
+			var data interface{}
+			if len(out) == 2 {
+				data = out[0].Interface()
+			}
+
+			if err := out[len(out)-1]; !err.IsNil() {
+				return data, err.Interface()
+			}
+
+			return data, nil
+		})(w, req, ps)
+	}, nil
+}
+
+// isBodyType reports whether a argument of the type can be decoded from the request body.
+func isBodyType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem().Kind() == reflect.Struct
+	}
+
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice:
+		return true
+	}
+
+	return false
+}
+
+// bindBody decodes the request body into a new value of the type.
+func bindBody(c *Context, t reflect.Type) (reflect.Value, error) {
+	if t.Kind() == reflect.Ptr {
+		v := reflect.New(t.Elem())
+		return v, c.Bind(v.Interface())
+	}
+
+	v := reflect.New(t)
+	return v.Elem(), c.Bind(v.Interface())
+}
@@ -0,0 +1,111 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReflectHandle(t *testing.T) {
+	type user struct {
+		ID string `json:"id"`
+	}
+
+	router := NewRouter()
+	router.Get("/users/:id", func(ctx context.Context, r *http.Request, ps Params) (*user, error) {
+		return &user{ID: ps.ByName("id")}, nil
+	})
+	router.Delete("/users/:id", func(ps Params) error {
+		return NewError(http.StatusForbidden, "not allowed")
+	})
+	router.Get("/count", func() (int, error) {
+		return 0, NewError(http.StatusInternalServerError, "failed")
+	})
+	router.Post("/users", func(ctx context.Context, in *user) (*user, error) {
+		return in, nil
+	})
+	router.Put("/tags", func(tags []string) (int, error) {
+		return len(tags), nil
+	})
+
+	tests := []struct {
+		method string
+		path   string
+		input  string
+		status int
+		body   string
+	}{
+		{"GET", "/users/1", "", http.StatusOK, `{"id":"1"}`},
+		{"DELETE", "/users/1", "", http.StatusForbidden, `{"error":"not allowed"}`},
+		{"GET", "/count", "", http.StatusInternalServerError, `{"error":"failed"}`},
+		{"POST", "/users", `{"id":"2"}`, http.StatusOK, `{"id":"2"}`},
+		{"POST", "/users", `{"id":`, http.StatusBadRequest, `{"error":"unexpected end of JSON input"}`},
+		{"PUT", "/tags", `["a","b"]`, http.StatusOK, `2`},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(test.method, test.path, strings.NewReader(test.input))
+		router.ServeHTTP(w, r)
+
+		if w.Code != test.status {
+			t.Errorf("%s %s: wrong status: want %d, got %d", test.method, test.path, test.status, w.Code)
+		}
+
+		if w.Body.String() != test.body {
+			t.Errorf("%s %s: wrong body: want %s, got %s", test.method, test.path, test.body, w.Body.String())
+		}
+	}
+
+	if errs := router.Errors(); len(errs) != 0 {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+}
+
+func TestRegistrationErrors(t *testing.T) {
+	router := NewRouter()
+	group := router.Group("/api")
+	group.Get("/users", func(r *http.Request) (string, int) {
+		return "", 0
+	})
+	group.Get("/teams", "not a handle")
+	group.Post("/teams", func(a, b map[string]string) error {
+		return nil
+	})
+	group.Use(func(h http.HandlerFunc) http.HandlerFunc {
+		return h
+	})
+
+	errs := router.Errors()
+	if len(errs) != 4 {
+		t.Fatalf("wrong number of errors: want 4, got %d", len(errs))
+	}
+
+	if !strings.Contains(errs[0].Error(), "GET /api/users") || !strings.Contains(errs[0].Error(), "must return a error") {
+		t.Errorf("wrong error: got %q", errs[0])
+	}
+
+	if !strings.Contains(errs[2].Error(), "only one argument") {
+		t.Errorf("wrong error: got %q", errs[2])
+	}
+
+	if !strings.Contains(errs[3].Error(), "unsupported middleware type") {
+		t.Errorf("wrong error: got %q", errs[3])
+	}
+
+	router = NewRouter()
+	api := router.Group("/api")
+	router.SetStrict(true)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("strict router did not panic")
+		}
+	}()
+
+	api.Get("/users", func(int) (interface{}, error) {
+		return nil, nil
+	})
+}
@@ -0,0 +1,71 @@
+package httpapi
+
+import (
+	"bytes"
+	"net/http"
+	"time"
+)
+
+// Response can be returned from a handle to respond with a status code,
+// headers and validators together with the data.
+type Response struct {
+	// Status is the status code, 200 OK is used if it's zero.
+	Status int
+
+	// Header contains headers that will be added to the response.
+	Header http.Header
+
+	// Data is the data that will be encoded.
+	Data interface{}
+
+	// ETag is the entity tag of the data, e.g "v1" or W/"v1".
+	// It's computed from the encoded data when empty and ETags are enabled in the settings.
+	ETag string
+
+	// LastModified is when the data was last modified.
+	LastModified time.Time
+}
+
+// responseBuffer is a http.ResponseWriter that buffers the response.
+type responseBuffer struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+// newResponseBuffer creates a new response buffer.
+func newResponseBuffer() *responseBuffer {
+	return &responseBuffer{
+		header: make(http.Header),
+	}
+}
+
+// Header returns the header map.
+func (b *responseBuffer) Header() http.Header {
+	return b.header
+}
+
+// Write writes the data to the buffer.
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+
+	return b.body.Write(p)
+}
+
+// WriteHeader sets the status code if it's not already set.
+func (b *responseBuffer) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+// Status returns the status code of the response.
+func (b *responseBuffer) Status() int {
+	if b.status == 0 {
+		return http.StatusOK
+	}
+
+	return b.status
+}
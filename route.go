@@ -0,0 +1,104 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+type routeKey struct{}
+
+// Route represents a route that is registered.
+type Route struct {
+	// Method is the HTTP method of the route.
+	Method string
+
+	// Path is the full path of the route without constraints.
+	Path string
+
+	// Settings is the settings of the route, a copy of the router settings.
+	Settings *Settings
+
+	// Name is the name of the route, set with the Name option.
+	Name string
+
+	// Handle is the handle the route was registered with.
+	Handle interface{}
+
+	constraints []paramConstraint
+	raw         bool
+	mount       bool
+}
+
+// RouteFromContext pulls the route that matched the request from a request context, or returns nil if none are present.
+// The path of a route of a router mounted with Mount has the mount prefix, e.g "/api/users/:id".
+func RouteFromContext(ctx context.Context) *Route {
+	route, _ := ctx.Value(routeKey{}).(*Route)
+	return route
+}
+
+// RouteOption configures a route when it's registered.
+type RouteOption func(*Route)
+
+// Raw returns a route option that registers the route without the middlewares of the router.
+func Raw() RouteOption {
+	return func(r *Route) {
+		r.raw = true
+	}
+}
+
+// Name returns a route option that names the route, e.g "getUser". The name is
+// used by generated clients as the method name.
+func Name(name string) RouteOption {
+	return func(r *Route) {
+		r.Name = name
+	}
+}
+
+// newRoute creates a route with a copy of the router settings and applies the options.
+func (r *Router) newRoute(method, path string, opts []RouteOption) *Route {
+	path, constraints := parsePathConstraints(path)
+
+	// Use a copy of the settings so changes after registration don't affect the route.
+	route := &Route{
+		Method:      method,
+		Path:        r.joinPath(path),
+		Settings:    r.Settings.clone(),
+		constraints: constraints,
+	}
+
+	for _, opt := range opts {
+		opt(route)
+	}
+
+	return route
+}
+
+// then returns the handler wrapped with the settings of the route and the middlewares
+// of the router, unless the route is raw.
+func (r *Router) then(route *Route, handler http.Handler) http.Handler {
+	handler = route.handler(route.Settings.handler(handler))
+
+	if route.raw {
+		return handler
+	}
+
+	// Append middlewares using alice.
+	return r.middlewares.Then(handler)
+}
+
+// handler returns a handler that puts the route in the request context before calling next.
+func (route *Route) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rt := route
+
+		// Routes of a mounted router don't know the mount prefix that was stripped.
+		if m := RouteFromContext(r.Context()); m != nil && m.mount && m != route {
+			c := *route
+			c.Path = strings.TrimSuffix(m.Path, "/") + route.Path
+			rt = &c
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), routeKey{}, rt)))
+	})
+}
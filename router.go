@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/justinas/alice"
@@ -16,16 +18,16 @@ import (
 type Param = httprouter.Param
 
 // Params is a Param-slice, as returned by the router.
-// Just a alias for httprouter.Params.
-type Params = httprouter.Params
+// It has the same methods as httprouter.Params and typed accessors for the values.
+type Params []Param
 
 // Handle is httprouter's function that can be in middlewares.
 // Handle is a function that can be registered to a route to handle HTTP requests.
-// Just a alias for httprouter.Handle.
-type Handle = httprouter.Handle
+// It's the same as httprouter.Handle but with httpapi's Params.
+type Handle func(http.ResponseWriter, *http.Request, Params)
 
 // HandleFunc is a function that can be registered to be a route to handle HTTP requests.
-type HandleFunc func(r *http.Request, ps httprouter.Params) (interface{}, interface{})
+type HandleFunc func(r *http.Request, ps Params) (interface{}, interface{})
 
 // HandleFunc2 is a function that can be registered to be a route to handle HTTP requests.
 // HandleFunc2 does only have http request as a argument.
@@ -39,24 +41,38 @@ type HandleFunc3 func() (interface{}, interface{})
 // HandleFunc4 does not have any arguments.
 type HandleFunc4 func() (interface{}, interface{})
 
+// methods contains all methods that a mounted handler is registered for.
+var methods = []string{"GET", "HEAD", "OPTIONS", "POST", "PUT", "PATCH", "DELETE", "CONNECT", "TRACE"}
+
 // ParamsFromContext pulls the URL parameters from a request context, or returns nil if none are present.
 // Just a alias function for httprouter.ParamsFromContext.
 func ParamsFromContext(ctx context.Context) Params {
-	return httprouter.ParamsFromContext(ctx)
+	return Params(httprouter.ParamsFromContext(ctx))
+}
+
+// ByName returns the value of the first Param which key matches the given name.
+// If no matching Param is found, an empty string is returned.
+func (ps Params) ByName(name string) string {
+	return httprouter.Params(ps).ByName(name)
 }
 
 // Router represents the router.
 type Router struct {
 	path           string
 	router         *httprouter.Router
+	registry       *registry
+	matchers       []matcher
+	vary           []string
 	middlewares    alice.Chain
-	ResponseHandle func(HandleFunc) httprouter.Handle
+	ResponseHandle func(HandleFunc) Handle
+	Settings       *Settings
 }
 
 // NewRouter creates a new router.
 func NewRouter(args ...*httprouter.Router) *Router {
 	r := &Router{
 		middlewares: alice.New(),
+		registry:    newRegistry(),
 	}
 
 	if len(args) > 0 {
@@ -66,18 +82,26 @@ func NewRouter(args ...*httprouter.Router) *Router {
 	}
 
 	r.ResponseHandle = DefaultResponseHandle
+	r.Settings = &Settings{}
 
 	return r
 }
 
 // Handle adds a new handle to a path and method.
-func (r *Router) Handle(method, path string, handle interface{}) {
+// Path params can have constraints, e.g "/users/:id<int>", see ParseConstraint.
+func (r *Router) Handle(method, path string, handle interface{}, opts ...RouteOption) {
 	var handler http.Handler
 
 	// Wrap different versions of api handle functions.
 	switch h := handle.(type) {
-	case func(r *http.Request, ps httprouter.Params) (interface{}, interface{}):
+	case func(r *http.Request, ps Params) (interface{}, interface{}):
+		handler = r.wrapHandle(r.ResponseHandle(h))
+	case HandleFunc:
 		handler = r.wrapHandle(r.ResponseHandle(h))
+	case func(r *http.Request, ps httprouter.Params) (interface{}, interface{}):
+		handler = r.wrapHandle(r.ResponseHandle(func(r *http.Request, ps Params) (interface{}, interface{}) {
+			return h(r, httprouter.Params(ps))
+		}))
 	case func(r *http.Request) (interface{}, interface{}):
 		handler = r.wrapHandle(r.ResponseHandle(func(r *http.Request, _ Params) (interface{}, interface{}) {
 			return h(r)
@@ -90,25 +114,59 @@ func (r *Router) Handle(method, path string, handle interface{}) {
 		handler = r.wrapHandle(r.ResponseHandle(func(r *http.Request, _ Params) (interface{}, interface{}) {
 			return h()
 		}))
+	case func(ctx context.Context, ps Params) (interface{}, error):
+		handler = r.wrapHandle(r.ResponseHandle(func(r *http.Request, ps Params) (interface{}, interface{}) {
+			return h(r.Context(), ps)
+		}))
+	case func(c *Context) (interface{}, error):
+		handler = r.wrapHandle(r.contextHandle(h))
 	case func(w http.ResponseWriter, r *http.Request, ps Params):
 		handler = r.wrapHandle(h)
+	case Handle:
+		handler = r.wrapHandle(h)
+	case func(w http.ResponseWriter, r *http.Request, ps httprouter.Params):
+		handler = r.wrapHandle(fromHTTPRouterHandle(h))
+	case httprouter.Handle:
+		handler = r.wrapHandle(fromHTTPRouterHandle(h))
 	case func(w http.ResponseWriter, r *http.Request):
 		handler = r.wrapHandle(func(w http.ResponseWriter, r *http.Request, ps Params) {
 			h(w, r)
 		})
 	default:
-		return
+		fn, err := r.reflectHandle(handle)
+		if err != nil {
+			r.registrationError(fmt.Errorf("httpapi: %s %s: %v", method, r.joinPath(path), err))
+			return
+		}
+		handler = r.wrapHandle(fn)
 	}
 
-	// Append middlewares using alice.
-	handler = r.middlewares.Then(handler)
+	route := r.newRoute(method, path, opts)
+	route.Handle = handle
+
+	handler = preconditions(handler)
+
+	var matchers []matcher
+	if route.Settings.BadRequestOnConstraint {
+		handler = route.constraintHandler(handler)
+	} else if m := route.matcher(); m != nil {
+		matchers = append(matchers, m)
+	}
+
+	handler = r.then(route, r.timeout(handler))
 
 	// Route away!
-	r.router.Handler(method, r.joinPath(path), handler)
+	r.handle(route.Method, route.Path, handler, matchers...)
+	r.registry.routes = append(r.registry.routes, route)
 }
 
-// Group returns new *Router with given path and middlewares.
-// It should be used for handles which have same path prefix or common middlewares.
+// Routes returns the routes that has been registered on the router and its groups, in the order they were registered.
+func (r *Router) Routes() []*Route {
+	return append([]*Route(nil), r.registry.routes...)
+}
+
+// Group returns new *Router with given path, middlewares and a copy of the settings.
+// It should be used for handles which have same path prefix, common middlewares or settings.
 func (r *Router) Group(path string) *Router {
 	if path[len(path)-1] == '/' {
 		path = path[:len(path)-1]
@@ -117,49 +175,72 @@ func (r *Router) Group(path string) *Router {
 		middlewares:    r.middlewares,
 		path:           r.joinPath(path),
 		router:         r.router,
+		registry:       r.registry,
+		matchers:       r.matchers,
+		vary:           r.vary,
 		ResponseHandle: r.ResponseHandle,
+		Settings:       r.Settings.clone(),
 	}
 }
 
 // Handler is an adapter which allows the usage of an http.Handler as a
-// request handle. Just a alias function for httprouter's Handler.
-func (r *Router) Handler(method, path string, handler http.Handler) {
-	r.router.Handler(method, r.joinPath(path), handler)
+// request handle. The handler is registered like any other handle, with the
+// router's path, settings and middlewares.
+func (r *Router) Handler(method, path string, handler http.Handler, opts ...RouteOption) {
+	r.Handle(method, path, handler.ServeHTTP, opts...)
 }
 
-// Get is a shortcut for router.Handle("GET", path, handle).
-func (r *Router) Get(path string, handle interface{}) {
-	r.Handle("GET", path, handle)
+// Mount mounts a http.Handler, e.g a another router, at the given path prefix for all methods.
+// The prefix is stripped from the request path before the handler is called and the
+// router's settings and middlewares are applied.
+func (r *Router) Mount(prefix string, handler http.Handler, opts ...RouteOption) {
+	route := r.newRoute("", prefix, opts)
+	route.mount = true
+	prefix = strings.TrimSuffix(route.Path, "/")
+	handler = r.then(route, stripPrefix(prefix, handler))
+
+	for _, method := range methods {
+		if prefix != "" {
+			r.handle(method, prefix, handler)
+		}
+
+		r.handle(method, prefix+"/*mountpath", handler)
+	}
 }
 
-// Head is a shortcut for router.Handle("HEAD", path, handle).
-func (r *Router) Head(path string, handle interface{}) {
-	r.Handle("HEAD", path, handle)
+// Get is a shortcut for router.Handle("GET", path, handle, opts...).
+func (r *Router) Get(path string, handle interface{}, opts ...RouteOption) {
+	r.Handle("GET", path, handle, opts...)
 }
 
-// Options is a shortcut for router.Handle("OPTIONS", path, handle).
-func (r *Router) Options(path string, handle interface{}) {
-	r.Handle("OPTIONS", path, handle)
+// Head is a shortcut for router.Handle("HEAD", path, handle, opts...).
+func (r *Router) Head(path string, handle interface{}, opts ...RouteOption) {
+	r.Handle("HEAD", path, handle, opts...)
 }
 
-// Post is a shortcut for router.Handle("POST", path, handle).
-func (r *Router) Post(path string, handle interface{}) {
-	r.Handle("POST", path, handle)
+// Options is a shortcut for router.Handle("OPTIONS", path, handle, opts...).
+func (r *Router) Options(path string, handle interface{}, opts ...RouteOption) {
+	r.Handle("OPTIONS", path, handle, opts...)
 }
 
-// Put is a shortcut for router.Handle("PUT", path, handle).
-func (r *Router) Put(path string, handle interface{}) {
-	r.Handle("PUT", path, handle)
+// Post is a shortcut for router.Handle("POST", path, handle, opts...).
+func (r *Router) Post(path string, handle interface{}, opts ...RouteOption) {
+	r.Handle("POST", path, handle, opts...)
 }
 
-// Patch is a shortcut for router.Handle("PATCH", path, handle).
-func (r *Router) Patch(path string, handle interface{}) {
-	r.Handle("PATCH", path, handle)
+// Put is a shortcut for router.Handle("PUT", path, handle, opts...).
+func (r *Router) Put(path string, handle interface{}, opts ...RouteOption) {
+	r.Handle("PUT", path, handle, opts...)
 }
 
-// Delete is a shortcut for router.Handle("DELETE", path, handle).
-func (r *Router) Delete(path string, handle interface{}) {
-	r.Handle("DELETE", path, handle)
+// Patch is a shortcut for router.Handle("PATCH", path, handle, opts...).
+func (r *Router) Patch(path string, handle interface{}, opts ...RouteOption) {
+	r.Handle("PATCH", path, handle, opts...)
+}
+
+// Delete is a shortcut for router.Handle("DELETE", path, handle, opts...).
+func (r *Router) Delete(path string, handle interface{}, opts ...RouteOption) {
+	r.Handle("DELETE", path, handle, opts...)
 }
 
 // Use appends a MiddlewareFunc to the chain.
@@ -186,16 +267,78 @@ func (r *Router) Use(mwf ...interface{}) {
 					h.ServeHTTP(w, r)
 				})
 			})
+		case func(http.Handler) httprouter.Handle:
+			r.Use(func(h http.Handler) Handle {
+				return fromHTTPRouterHandle(m(h))
+			})
+		case func(httprouter.Handle) httprouter.Handle:
+			r.Use(func(h Handle) Handle {
+				return fromHTTPRouterHandle(m(func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+					h(w, r, Params(ps))
+				}))
+			})
+		case func(httprouter.Handle) http.Handler:
+			r.Use(func(h Handle) http.Handler {
+				return m(func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+					h(w, r, Params(ps))
+				})
+			})
 		default:
+			r.registrationError(fmt.Errorf("httpapi: unsupported middleware type %T", mw))
 		}
 	}
 }
 
+// Errors returns the errors of handles and middlewares with unsupported types
+// that has been registered on the router or any of its groups.
+func (r *Router) Errors() []error {
+	return append([]error(nil), r.registry.errors...)
+}
+
+// SetStrict makes Handle and Use panic when a handle or middleware has a unsupported
+// type. Otherwise the errors is collected, see Errors. It applies to the router and
+// all of its groups, also groups that are created before it's called.
+func (r *Router) SetStrict(strict bool) {
+	r.registry.strict = strict
+}
+
+// registrationError panics with the error in strict mode, otherwise the error is collected.
+func (r *Router) registrationError(err error) {
+	if r.registry.strict {
+		panic(err)
+	}
+
+	r.registry.errors = append(r.registry.errors, err)
+}
+
 // ServeFiles serves files from the given file system root.
-// Just a alias function for httprouter's ServeFiles.
+// The path must end with "/*filepath", files are then served from the local path /defined/root/dir/*filepath.
+// It works like httprouter's ServeFiles but the router's path, settings and middlewares are applied.
 // Read more: https://godoc.org/github.com/julienschmidt/httprouter#Router.ServeFiles
-func (r *Router) ServeFiles(path string, root http.FileSystem) {
-	r.router.ServeFiles(path, root)
+func (r *Router) ServeFiles(path string, root http.FileSystem, opts ...RouteOption) {
+	if !strings.HasSuffix(path, "/*filepath") {
+		panic("path must end with /*filepath in path '" + path + "'")
+	}
+
+	fileServer := http.FileServer(root)
+
+	r.Handle("GET", path, func(w http.ResponseWriter, req *http.Request, ps Params) {
+		r2 := new(http.Request)
+		*r2 = *req
+		r2.URL = new(url.URL)
+		*r2.URL = *req.URL
+		r2.URL.Path = ps.ByName("filepath")
+		r2.URL.RawPath = ""
+		fileServer.ServeHTTP(w, r2)
+	}, opts...)
+}
+
+// NotFound sets the handler that is called when no route matches a request to the
+// router's path, the handler of the group with the longest matching path is used.
+// The router's settings and middlewares are applied.
+func (r *Router) NotFound(handler http.Handler, opts ...RouteOption) {
+	route := r.newRoute("", "", opts)
+	r.registry.addNotFound(r.router, strings.TrimSuffix(route.Path, "/"), r.matchers, r.vary, r.then(route, handler))
 }
 
 // ServeHTTP makes the router implement the http.Handler interface.
@@ -205,42 +348,90 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 }
 
 // DefaultResponseHandle is the default response handle.
+// Data is encoded with the encoder from the router settings that matches the request
+// and errors are handled by the error handle from the router settings. Return a
+// *Response to respond with a status code, headers or validators.
 func DefaultResponseHandle(fn HandleFunc) Handle {
 	return func(w http.ResponseWriter, req *http.Request, ps Params) {
 		data, err := fn(req, ps)
+		s := settingsFromRequest(req)
 
 		if err == nil {
-			WriteJSON(w, data)
+			res, ok := data.(*Response)
+			if ok {
+				c := *res
+				res = &c
+			} else {
+				res = &Response{Data: data}
+			}
+
+			data, err := s.selectFields(req, res.Data)
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+
+			if p, ok := data.(*Page); ok {
+				data = p.render(w, req)
+			}
+
+			res.Data = data
+			s.respond(w, req, res)
 			return
 		}
 
 		if err, ok := err.(error); ok {
-			msg := err.Error()
-
-			if msg[0] == '{' && msg[len(msg)-1] == '}' || msg[0] == '[' && msg[len(msg)-1] == ']' {
-				fmt.Fprintf(w, msg)
-			} else {
-				WriteJSON(w, map[string]interface{}{
-					"error": msg,
-				})
-			}
+			s.handleError(w, req, err)
 		}
 	}
 }
 
 // joinPath joins base router path and input path.
 func (r *Router) joinPath(path string) string {
+	if path == "" {
+		return r.path
+	}
+
 	if (r.path + path)[0] != '/' {
 		panic("path must begin with '/' in path '" + path + "'")
 	}
 
-	if path == "/" {
+	if path == "/" && r.path != "" {
 		return r.path
 	}
 
 	return r.path + path
 }
 
+// stripPrefix returns a handler that removes the given prefix from the request path.
+// Unlike http.StripPrefix the path will always begin with a '/'.
+func stripPrefix(prefix string, next http.Handler) http.Handler {
+	if prefix == "" {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.URL = new(url.URL)
+		*r2.URL = *r.URL
+		r2.URL.Path = "/" + strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, prefix), "/")
+
+		if r.URL.RawPath != "" {
+			r2.URL.RawPath = "/" + strings.TrimPrefix(strings.TrimPrefix(r.URL.RawPath, prefix), "/")
+		}
+
+		next.ServeHTTP(w, r2)
+	})
+}
+
+// fromHTTPRouterHandle converts a httprouter.Handle to a Handle.
+func fromHTTPRouterHandle(h httprouter.Handle) Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps Params) {
+		h(w, r, httprouter.Params(ps))
+	}
+}
+
 // wrap wraps httprouter.Handle with http.Handler
 func (r *Router) wrapHandle(next Handle) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -251,6 +442,12 @@ func (r *Router) wrapHandle(next Handle) http.Handler {
 // WriteJSON writes interface as JSON to response writer.
 // If a error occurred a internal server error status will be written.
 func WriteJSON(w http.ResponseWriter, v interface{}) error {
+	return writeJSON(w, 0, v)
+}
+
+// writeJSON writes interface as JSON to response writer with the given status code.
+// The status code is only written if it's not zero.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) error {
 	js, err := json.Marshal(v)
 	if err != nil {
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
@@ -258,6 +455,9 @@ func WriteJSON(w http.ResponseWriter, v interface{}) error {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	if status != 0 {
+		w.WriteHeader(status)
+	}
 	w.Write(js)
 
 	return nil
@@ -3,7 +3,9 @@ package httpapi
 import (
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -378,3 +380,195 @@ func TestGroupIndexCase(t *testing.T) {
 		t.Error("routing GET GROUP failed")
 	}
 }
+
+func TestMount(t *testing.T) {
+	var paths []string
+
+	sub := NewRouter()
+	sub.Get("/", func(r *http.Request) (interface{}, interface{}) {
+		paths = append(paths, r.URL.Path)
+		return nil, nil
+	})
+	sub.Post("/users/:name", func(r *http.Request, ps Params) (interface{}, interface{}) {
+		paths = append(paths, r.URL.Path+":"+ps.ByName("name"))
+		return nil, nil
+	})
+
+	router := NewRouter()
+	router.Mount("/sub", sub)
+
+	w := new(mockResponseWriter)
+
+	r, _ := http.NewRequest("GET", "/sub", nil)
+	router.ServeHTTP(w, r)
+
+	r, _ = http.NewRequest("GET", "/sub/", nil)
+	router.ServeHTTP(w, r)
+
+	r, _ = http.NewRequest("POST", "/sub/users/fredrik", nil)
+	router.ServeHTTP(w, r)
+
+	want := []string{"/", "/", "/users/fredrik:fredrik"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("mounting failed: want %v, got %v", want, paths)
+	}
+}
+
+func TestRouteFromContext(t *testing.T) {
+	var paths []string
+
+	handle := func(r *http.Request) (interface{}, interface{}) {
+		paths = append(paths, RouteFromContext(r.Context()).Path)
+		return nil, nil
+	}
+
+	sub := NewRouter()
+	sub.Get("/users/:id", handle)
+
+	router := NewRouter()
+	router.Group("/api").Get("/users", handle)
+	router.Mount("/sub", sub)
+
+	w := new(mockResponseWriter)
+
+	r, _ := http.NewRequest("GET", "/api/users", nil)
+	router.ServeHTTP(w, r)
+
+	r, _ = http.NewRequest("GET", "/sub/users/1", nil)
+	router.ServeHTTP(w, r)
+
+	want := []string{"/api/users", "/sub/users/:id"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("wrong route paths: want %v, got %v", want, paths)
+	}
+}
+
+func TestMountGroup(t *testing.T) {
+	var path string
+	var calls int
+
+	router := NewRouter()
+	group := router.Group("/FOO")
+	group.Use(func(h http.Handler) http.Handler {
+		calls++
+		return h
+	})
+	group.Mount("/BAR", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+	}))
+
+	w := new(mockResponseWriter)
+
+	r, _ := http.NewRequest("DELETE", "/FOO/BAR/baz", nil)
+	router.ServeHTTP(w, r)
+	if path != "/baz" {
+		t.Errorf("mounting in group failed: want /baz, got %s", path)
+	}
+
+	if calls != 1 {
+		t.Error("middlewares not applied to mounted handler")
+	}
+}
+
+func TestHandlerGroup(t *testing.T) {
+	var calls int
+
+	router := NewRouter()
+	group := router.Group("/api")
+	group.Use(func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			h.ServeHTTP(w, r)
+		})
+	})
+	group.Handler("GET", "/users", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	group.Handler("GET", "/raw", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), Raw())
+
+	w := new(mockResponseWriter)
+
+	r, _ := http.NewRequest("GET", "/api/users", nil)
+	router.ServeHTTP(w, r)
+	if calls != 1 {
+		t.Errorf("middlewares not applied to handler: want 1 call, got %d", calls)
+	}
+
+	r, _ = http.NewRequest("GET", "/api/raw", nil)
+	router.ServeHTTP(w, r)
+	if calls != 1 {
+		t.Errorf("middlewares applied to raw handler: want 1 call, got %d", calls)
+	}
+}
+
+func TestServeFilesGroup(t *testing.T) {
+	var calls int
+
+	router := NewRouter()
+	group := router.Group("/static")
+	group.Use(func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			h.ServeHTTP(w, r)
+		})
+	})
+	group.ServeFiles("/*filepath", http.Dir("."))
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/static/license", nil)
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "MIT") {
+		t.Errorf("file not served: got %d", w.Code)
+	}
+
+	if calls != 1 {
+		t.Error("middlewares not applied to served files")
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	router := NewRouter()
+	router.NotFound(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("root"))
+	}))
+	router.Get("/users", func() (interface{}, interface{}) {
+		return nil, nil
+	})
+
+	group := router.Group("/api")
+	group.Use(func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Group", "api")
+			h.ServeHTTP(w, r)
+		})
+	})
+	group.NotFound(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("api"))
+	}))
+
+	tests := []struct {
+		path  string
+		body  string
+		group string
+	}{
+		{"/missing", "root", ""},
+		{"/apis", "root", ""},
+		{"/api", "api", "api"},
+		{"/api/missing", "api", "api"},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", test.path, nil)
+		router.ServeHTTP(w, r)
+
+		if w.Code != http.StatusNotFound || w.Body.String() != test.body {
+			t.Errorf("%s: wrong response: want 404 %s, got %d %s", test.path, test.body, w.Code, w.Body.String())
+		}
+
+		if v := w.Header().Get("X-Group"); v != test.group {
+			t.Errorf("%s: wrong middleware header: want %q, got %q", test.path, test.group, v)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Server serves a handler, e.g a router, with timeouts and graceful shutdown.
+// The server is ready while it's listening and not ready during shutdown, so
+// readiness checks fail and load balancers stops sending requests to it.
+type Server struct {
+	// Addr is the TCP address to listen on, ":http" or ":https" is used if empty.
+	Addr string
+
+	// Handler is the handler that serves the requests.
+	Handler http.Handler
+
+	// TLSConfig is the TLS configuration used by ListenAndServeTLS.
+	TLSConfig *tls.Config
+
+	// ReadTimeout, ReadHeaderTimeout, WriteTimeout and IdleTimeout is the
+	// timeouts of the http.Server, see the http.Server documentation.
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+
+	// ShutdownTimeout is the maximum duration to drain in-flight requests and run
+	// the shutdown hooks when shutting down because of a signal. Connections that
+	// are still open after the timeout are closed.
+	ShutdownTimeout time.Duration
+
+	// ShutdownDelay is the duration to wait after the server is no longer ready
+	// before it stops accepting new connections, so load balancers has time to notice.
+	ShutdownDelay time.Duration
+
+	// Signals is the signals that starts a graceful shutdown. No signals is handled if empty.
+	Signals []os.Signal
+
+	// ErrorLog is the logger of the http.Server, the log package's standard logger is used if nil.
+	ErrorLog *log.Logger
+
+	mu          sync.Mutex
+	server      *http.Server
+	hooks       []func(ctx context.Context) error
+	ready       int32
+	shutdown    bool
+	shutdownErr error
+	done        chan struct{}
+}
+
+// NewServer creates a new server for the handler with default timeouts and graceful
+// shutdown on interrupt and SIGTERM.
+func NewServer(addr string, handler http.Handler) *Server {
+	return &Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadTimeout:       30 * time.Second,
+		ReadHeaderTimeout: 10 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       120 * time.Second,
+		ShutdownTimeout:   30 * time.Second,
+		Signals:           []os.Signal{os.Interrupt, syscall.SIGTERM},
+	}
+}
+
+// OnShutdown adds a hook that is called after the in-flight requests has been drained,
+// e.g to close database connections. Hooks are called in reverse order of addition.
+func (s *Server) OnShutdown(fn func(ctx context.Context) error) {
+	s.mu.Lock()
+	s.hooks = append(s.hooks, fn)
+	s.mu.Unlock()
+}
+
+// Ready reports whether the server is listening and not shutting down.
+func (s *Server) Ready() bool {
+	return atomic.LoadInt32(&s.ready) == 1
+}
+
+// ListenAndServe listens on the TCP address of the server and serves requests until
+// the server is shut down. It returns nil when the server is shut down gracefully.
+func (s *Server) ListenAndServe() error {
+	addr := s.Addr
+	if addr == "" {
+		addr = ":http"
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(l)
+}
+
+// ListenAndServeTLS is like ListenAndServe but serves HTTPS requests with the certificate and key files.
+func (s *Server) ListenAndServeTLS(certFile, keyFile string) error {
+	addr := s.Addr
+	if addr == "" {
+		addr = ":https"
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return s.serve(l, func(srv *http.Server) error {
+		return srv.ServeTLS(l, certFile, keyFile)
+	})
+}
+
+// Serve serves requests on the listener until the server is shut down.
+// It returns nil when the server is shut down gracefully.
+func (s *Server) Serve(l net.Listener) error {
+	return s.serve(l, func(srv *http.Server) error {
+		return srv.Serve(l)
+	})
+}
+
+// serve starts the http.Server with the serve function and shuts it down when
+// any of the signals is received.
+func (s *Server) serve(l net.Listener, serve func(*http.Server) error) error {
+	srv := &http.Server{
+		Addr:              l.Addr().String(),
+		Handler:           s.Handler,
+		TLSConfig:         s.TLSConfig,
+		ReadTimeout:       s.ReadTimeout,
+		ReadHeaderTimeout: s.ReadHeaderTimeout,
+		WriteTimeout:      s.WriteTimeout,
+		IdleTimeout:       s.IdleTimeout,
+		ErrorLog:          s.ErrorLog,
+	}
+
+	s.mu.Lock()
+	if s.server != nil || s.shutdown {
+		s.mu.Unlock()
+		l.Close()
+		return errors.New("httpapi: server has already been started or shut down")
+	}
+	s.server = srv
+	if s.done == nil {
+		s.done = make(chan struct{})
+	}
+	s.mu.Unlock()
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	if len(s.Signals) > 0 {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, s.Signals...)
+		defer signal.Stop(signals)
+
+		go func() {
+			select {
+			case <-signals:
+			case <-stop:
+				return
+			}
+
+			ctx := context.Background()
+			if s.ShutdownTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, s.ShutdownTimeout)
+				defer cancel()
+			}
+
+			s.Shutdown(ctx)
+		}()
+	}
+
+	// The server is not marked as ready if it's shut down before serving.
+	s.mu.Lock()
+	if !s.shutdown {
+		atomic.StoreInt32(&s.ready, 1)
+	}
+	s.mu.Unlock()
+
+	err := serve(srv)
+	atomic.StoreInt32(&s.ready, 0)
+
+	if err != http.ErrServerClosed {
+		return err
+	}
+
+	// Wait for the in-flight requests to be drained and the hooks to be called.
+	<-s.done
+
+	return s.shutdownErr
+}
+
+// Shutdown gracefully shuts down the server. The server is marked as not ready, and after
+// the shutdown delay it stops accepting connections and waits for in-flight requests to
+// finish before the shutdown hooks are called. If the context expires before the requests
+// are drained the remaining connections are closed and the context's error is returned.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	if s.done == nil {
+		s.done = make(chan struct{})
+	}
+	done := s.done
+
+	if s.shutdown {
+		s.mu.Unlock()
+		<-done
+		return s.shutdownErr
+	}
+
+	s.shutdown = true
+	srv := s.server
+	hooks := append([]func(context.Context) error(nil), s.hooks...)
+	s.mu.Unlock()
+
+	atomic.StoreInt32(&s.ready, 0)
+
+	var err error
+
+	if srv != nil {
+		if s.ShutdownDelay > 0 {
+			select {
+			case <-time.After(s.ShutdownDelay):
+			case <-ctx.Done():
+			}
+		}
+
+		if err = srv.Shutdown(ctx); err != nil {
+			srv.Close()
+		}
+	}
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if herr := hooks[i](ctx); herr != nil && err == nil {
+			err = herr
+		}
+	}
+
+	s.shutdownErr = err
+	close(done)
+
+	return err
+}
@@ -0,0 +1,128 @@
+package httpapi
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestServerShutdown(t *testing.T) {
+	started := make(chan struct{})
+
+	router := NewRouter()
+	router.Get("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte("done"))
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewServer("", router)
+	s.Signals = nil
+
+	var order []string
+	s.OnShutdown(func(ctx context.Context) error {
+		order = append(order, "first")
+		return nil
+	})
+	s.OnShutdown(func(ctx context.Context) error {
+		order = append(order, "second")
+		return errors.New("hook failed")
+	})
+
+	served := make(chan error, 1)
+	go func() {
+		served <- s.Serve(l)
+	}()
+
+	body := make(chan string, 1)
+	go func() {
+		res, err := http.Get("http://" + l.Addr().String() + "/slow")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer res.Body.Close()
+		b, _ := ioutil.ReadAll(res.Body)
+		body <- string(b)
+	}()
+
+	<-started
+
+	if !s.Ready() {
+		t.Error("server is not ready while serving")
+	}
+
+	if err := s.Shutdown(context.Background()); err == nil || err.Error() != "hook failed" {
+		t.Errorf("wrong shutdown error: got %v", err)
+	}
+
+	if s.Ready() {
+		t.Error("server is ready after shutdown")
+	}
+
+	if b := <-body; b != "done" {
+		t.Errorf("in-flight request was not drained: got %q", b)
+	}
+
+	if err := <-served; err == nil || err.Error() != "hook failed" {
+		t.Errorf("wrong serve error: got %v", err)
+	}
+
+	if len(order) != 2 || order[0] != "second" || order[1] != "first" {
+		t.Errorf("wrong hook order: got %v", order)
+	}
+
+	if err := s.Serve(l); err == nil {
+		t.Error("server could be started after shutdown")
+	}
+}
+
+func TestServerSignal(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewServer("", NewRouter())
+	s.Signals = []os.Signal{os.Interrupt}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- s.Serve(l)
+	}()
+
+	for i := 0; !s.Ready(); i++ {
+		if i == 100 {
+			t.Fatal("server did not start")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.Signal(os.Interrupt); err != nil {
+		t.Skipf("signals is not supported: %v", err)
+	}
+
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("wrong serve error: got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("server was not shut down by the signal")
+	}
+}
@@ -0,0 +1,347 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type settingsKey struct{}
+
+// Encoder is a function that encodes a value to a writer.
+type Encoder func(w io.Writer, v interface{}) error
+
+// JSONEncoder encodes a value as JSON.
+func JSONEncoder(w io.Writer, v interface{}) error {
+	js, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(js)
+	return err
+}
+
+// Settings represents the settings that is used by a router when handling requests.
+// A group inherits a copy of the settings from the router it's created from
+// and can override them without affecting the parent router.
+type Settings struct {
+	// Encoders contains the encoders that the default response handle can
+	// respond with, keyed by content type. The encoder is selected by the
+	// Accept header of the request. JSON is used when no encoder matches.
+	Encoders map[string]Encoder
+
+	// ErrorHandle is called by the default response handle when a handle
+	// returns a error. DefaultErrorHandle is used if it's nil.
+	ErrorHandle func(w http.ResponseWriter, r *http.Request, err error)
+
+	// Headers contains headers that will be added to all responses.
+	Headers http.Header
+
+	// Timeout is the maximum duration of a request before the request context is
+	// canceled. If the handle hasn't returned by then the timeout error is responded
+	// with the response handle and later writes by the handle are discarded.
+	Timeout time.Duration
+
+	// TimeoutError is the error that is responded with when a request times out.
+	// A 503 Service Unavailable error is used if it's nil.
+	TimeoutError error
+
+	// MaxBodySize is the maximum size in bytes of a request body. Larger bodies
+	// is responded with 413 Request Entity Too Large, either before the handle
+	// is called or by the error returned when reading the body. The limit is
+	// applied to decompressed bodies too.
+	MaxBodySize int64
+
+	// DecompressBody makes gzip and deflate encoded request bodies be
+	// decompressed before they are read by the handle. Other encodings is
+	// responded with 415 Unsupported Media Type.
+	DecompressBody bool
+
+	// Auth is called before the handle is called. If a error is returned
+	// the error handle will respond with it, with 401 Unauthorized as the
+	// status code if the error don't have a status code.
+	Auth func(r *http.Request) error
+
+	// Validate is called after Auth, before the handle is called. If a error
+	// is returned the error handle will respond with it, with 400 Bad Request
+	// as the status code if the error don't have a status code.
+	Validate func(r *http.Request) error
+
+	// Idempotency is called after Validate, before the handle is called, e.g
+	// Idempotency(IdempotencyOptions{}). Requests that fail the body limit, Auth
+	// or Validate never reach it, so their errors are not stored and replayed.
+	Idempotency func(http.Handler) http.Handler
+
+	// Tags contains the OpenAPI tags of the routes.
+	Tags []string
+
+	// ETag makes the default response handle compute ETags from the encoded data
+	// and respond with 304 Not Modified to conditional GET and HEAD requests.
+	ETag ETagMode
+
+	// Preconditions returns the current ETag and Last-Modified of the resource of a
+	// unsafe request. When set, unsafe requests with If-Match or If-Unmodified-Since
+	// headers are responded with 412 Precondition Failed when they don't match, see
+	// CheckPreconditions. Use a empty ETag and zero time when the resource don't exist.
+	Preconditions func(r *http.Request) (etag string, lastModified time.Time, err error)
+
+	// SparseFields makes the default response handle select the fields of
+	// JSON responses from the "fields" query parameters, see SelectFields.
+	SparseFields bool
+
+	// BadRequestOnConstraint makes routes respond with 400 Bad Request when
+	// a path param don't match its constraint instead of trying the next
+	// route and respond with 404 Not Found.
+	BadRequestOnConstraint bool
+}
+
+// SettingsFromContext pulls the router settings from a request context, or returns nil if none are present.
+func SettingsFromContext(ctx context.Context) *Settings {
+	s, _ := ctx.Value(settingsKey{}).(*Settings)
+	return s
+}
+
+// settingsFromRequest returns the settings of a request or empty settings if none are present.
+func settingsFromRequest(r *http.Request) *Settings {
+	if s := SettingsFromContext(r.Context()); s != nil {
+		return s
+	}
+
+	return &Settings{}
+}
+
+// clone returns a copy of the settings.
+func (s *Settings) clone() *Settings {
+	if s == nil {
+		return &Settings{}
+	}
+
+	c := *s
+
+	if s.Encoders != nil {
+		c.Encoders = make(map[string]Encoder, len(s.Encoders))
+		for k, v := range s.Encoders {
+			c.Encoders[k] = v
+		}
+	}
+
+	if s.Headers != nil {
+		c.Headers = make(http.Header, len(s.Headers))
+		for k, v := range s.Headers {
+			c.Headers[k] = append([]string(nil), v...)
+		}
+	}
+
+	c.Tags = append([]string(nil), s.Tags...)
+
+	return &c
+}
+
+// handler returns a handler that applies the settings to the request before calling next.
+func (s *Settings) handler(next http.Handler) http.Handler {
+	if s.Idempotency != nil {
+		next = s.Idempotency(next)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(context.WithValue(r.Context(), settingsKey{}, s))
+
+		for k, v := range s.Headers {
+			w.Header()[k] = append([]string(nil), v...)
+		}
+
+		if err := s.limitBody(r); err != nil {
+			s.handleError(w, r, err)
+			return
+		}
+
+		if s.Auth != nil {
+			if err := s.Auth(r); err != nil {
+				if StatusCode(err) == 0 {
+					err = NewError(http.StatusUnauthorized, err.Error())
+				}
+
+				s.handleError(w, r, err)
+				return
+			}
+		}
+
+		if s.Validate != nil {
+			if err := s.Validate(r); err != nil {
+				if StatusCode(err) == 0 {
+					err = NewError(http.StatusBadRequest, err.Error())
+				}
+
+				s.handleError(w, r, err)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleError calls the error handle with the given error.
+func (s *Settings) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	if s.ErrorHandle != nil {
+		s.ErrorHandle(w, r, err)
+	} else {
+		DefaultErrorHandle(w, r, err)
+	}
+}
+
+// encoder returns the content type and encoder that best matches the accept header.
+// JSON is always available and used when no encoder matches.
+func (s *Settings) encoder(accept string) (string, Encoder) {
+	encoders := map[string]Encoder{
+		"application/json": JSONEncoder,
+	}
+
+	for k, v := range s.Encoders {
+		encoders[k] = v
+	}
+
+	for _, typ := range parseAccept(accept) {
+		if enc, ok := encoders[typ]; ok {
+			return typ, enc
+		}
+
+		if typ == "*/*" {
+			break
+		}
+
+		if strings.HasSuffix(typ, "/*") {
+			keys := make([]string, 0, len(encoders))
+			for k := range encoders {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+
+			for _, k := range keys {
+				if strings.HasPrefix(k, typ[:len(typ)-1]) {
+					return k, encoders[k]
+				}
+			}
+		}
+	}
+
+	return "application/json", encoders["application/json"]
+}
+
+// respond encodes the response data with the encoder that best matches the request and writes it
+// to the response writer with the response status code, headers and validators. GET and HEAD
+// requests are responded with 304 Not Modified when the validators match the request.
+// Responses with 204 No Content and 304 Not Modified status codes has no body, so their data
+// is not encoded. If a error occurred a internal server error status will be written.
+func (s *Settings) respond(w http.ResponseWriter, r *http.Request, res *Response) error {
+	status := res.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	noBody := status == http.StatusNoContent || status == http.StatusNotModified
+
+	typ, enc := s.encoder(r.Header.Get("Accept"))
+
+	var buf bytes.Buffer
+	if !noBody {
+		if err := enc(&buf, res.Data); err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return err
+		}
+	}
+
+	for k, v := range res.Header {
+		w.Header()[k] = append([]string(nil), v...)
+	}
+
+	// The encoder depends on the Accept header when there is more encoders than JSON.
+	for typ := range s.Encoders {
+		if typ != "application/json" {
+			addVary(w.Header(), "Accept")
+			break
+		}
+	}
+
+	etag := res.ETag
+	if etag == "" && !noBody && s.ETag != NoETag && status >= 200 && status < 300 {
+		etag = computeETag(buf.Bytes(), s.ETag)
+	}
+
+	if etag != "" {
+		etag = quoteETag(etag)
+		w.Header().Set("ETag", etag)
+	}
+
+	if !res.LastModified.IsZero() {
+		w.Header().Set("Last-Modified", res.LastModified.UTC().Format(http.TimeFormat))
+	}
+
+	if status == http.StatusOK && notModified(r, etag, res.LastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+
+	if noBody {
+		w.WriteHeader(status)
+		return nil
+	}
+
+	w.Header().Set("Content-Type", typ)
+	if res.Status != 0 {
+		w.WriteHeader(res.Status)
+	}
+	w.Write(buf.Bytes())
+
+	return nil
+}
+
+// parseAccept parses a accept header and returns the media types sorted by quality.
+func parseAccept(accept string) []string {
+	type mediaType struct {
+		name string
+		q    float64
+	}
+
+	var types []mediaType
+
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(params[0]))
+		if name == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, p := range params[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				if f, err := strconv.ParseFloat(p[2:], 64); err == nil {
+					q = f
+				}
+			}
+		}
+
+		if q > 0 {
+			types = append(types, mediaType{name, q})
+		}
+	}
+
+	sort.SliceStable(types, func(i, j int) bool {
+		return types[i].q > types[j].q
+	})
+
+	names := make([]string, len(types))
+	for i, t := range types {
+		names[i] = t.name
+	}
+
+	return names
+}
@@ -0,0 +1,208 @@
+package httpapi
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSettingsInheritance(t *testing.T) {
+	router := NewRouter()
+	router.Settings.Headers = http.Header{"X-Api": []string{"router"}}
+	router.Settings.Tags = []string{"public"}
+
+	admin := router.Group("/admin")
+	admin.Settings.Headers.Set("X-Api", "admin")
+	admin.Settings.Tags = append(admin.Settings.Tags, "admin")
+
+	var tags []string
+	handle := func(r *http.Request) (interface{}, interface{}) {
+		tags = SettingsFromContext(r.Context()).Tags
+		return nil, nil
+	}
+
+	router.Get("/users", handle)
+	admin.Get("/users", handle)
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/users", nil)
+	router.ServeHTTP(w, r)
+	if v := w.Header().Get("X-Api"); v != "router" {
+		t.Errorf("wrong header: want router, got %s", v)
+	}
+	if len(tags) != 1 {
+		t.Errorf("wrong tags: want [public], got %v", tags)
+	}
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("GET", "/admin/users", nil)
+	router.ServeHTTP(w, r)
+	if v := w.Header().Get("X-Api"); v != "admin" {
+		t.Errorf("wrong header: want admin, got %s", v)
+	}
+	if len(tags) != 2 {
+		t.Errorf("wrong tags: want [public admin], got %v", tags)
+	}
+}
+
+func TestSettingsEncoders(t *testing.T) {
+	router := NewRouter()
+	router.Settings.Encoders = map[string]Encoder{
+		"text/plain": func(w io.Writer, v interface{}) error {
+			_, err := fmt.Fprint(w, v)
+			return err
+		},
+	}
+	router.Get("/", func() (interface{}, interface{}) {
+		return "hello", nil
+	})
+
+	tests := []struct {
+		accept string
+		typ    string
+		body   string
+	}{
+		{"", "application/json", `"hello"`},
+		{"text/plain", "text/plain", "hello"},
+		{"text/*", "text/plain", "hello"},
+		{"application/xml, text/plain;q=0.5", "text/plain", "hello"},
+		{"text/plain;q=0.5, application/json", "application/json", `"hello"`},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept", test.accept)
+		router.ServeHTTP(w, r)
+
+		if v := w.Header().Get("Content-Type"); v != test.typ {
+			t.Errorf("wrong content type for %q: want %s, got %s", test.accept, test.typ, v)
+		}
+
+		if v := w.Body.String(); v != test.body {
+			t.Errorf("wrong body for %q: want %s, got %s", test.accept, test.body, v)
+		}
+
+		if v := w.Header().Get("Vary"); v != "Accept" {
+			t.Errorf("wrong vary header for %q: want Accept, got %s", test.accept, v)
+		}
+	}
+
+	router = NewRouter()
+	router.Get("/", func() (interface{}, interface{}) {
+		return "hello", nil
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/", nil)
+	router.ServeHTTP(w, r)
+
+	if v := w.Header().Get("Vary"); v != "" {
+		t.Errorf("vary header without encoders: got %s", v)
+	}
+}
+
+func TestSettingsNoContent(t *testing.T) {
+	router := NewRouter()
+	router.Settings.ETag = WeakETag
+	router.Delete("/users/:id", func(c *Context) (interface{}, error) {
+		return c.Respond(http.StatusNoContent, nil), nil
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("DELETE", "/users/1", nil)
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("wrong status: want %d, got %d", http.StatusNoContent, w.Code)
+	}
+
+	if w.Body.Len() != 0 || w.Header().Get("Content-Type") != "" || w.Header().Get("ETag") != "" {
+		t.Errorf("no content response has content: %v %q", w.Header(), w.Body.String())
+	}
+}
+
+func TestSettingsErrorHandle(t *testing.T) {
+	var handled error
+
+	router := NewRouter()
+	router.Settings.ErrorHandle = func(w http.ResponseWriter, r *http.Request, err error) {
+		handled = err
+	}
+	router.Get("/", func() (interface{}, interface{}) {
+		return nil, errors.New("fail")
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/", nil)
+	router.ServeHTTP(w, r)
+
+	if handled == nil || handled.Error() != "fail" {
+		t.Errorf("error handle not called: got %v", handled)
+	}
+}
+
+func TestSettingsAuth(t *testing.T) {
+	var called bool
+
+	router := NewRouter()
+	admin := router.Group("/admin")
+	admin.Settings.Auth = func(r *http.Request) error {
+		if r.Header.Get("Authorization") != "secret" {
+			return errors.New("unauthorized")
+		}
+		return nil
+	}
+	admin.Get("/", func() (interface{}, interface{}) {
+		called = true
+		return nil, nil
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/admin", nil)
+	router.ServeHTTP(w, r)
+	if called || w.Code != http.StatusUnauthorized {
+		t.Errorf("auth failed: want %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("GET", "/admin", nil)
+	r.Header.Set("Authorization", "secret")
+	router.ServeHTTP(w, r)
+	if !called {
+		t.Error("authorized request failed")
+	}
+}
+
+func TestSettingsTimeoutAndMaxBodySize(t *testing.T) {
+	var deadline bool
+	var readErr error
+
+	router := NewRouter()
+	router.Settings.Timeout = time.Second
+	router.Settings.MaxBodySize = 4
+	router.Post("/", func(r *http.Request) (interface{}, interface{}) {
+		_, deadline = r.Context().Deadline()
+		_, readErr = ioutil.ReadAll(r.Body)
+		return nil, nil
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("POST", "/", strings.NewReader("too large"))
+	r.ContentLength = -1
+	router.ServeHTTP(w, r)
+
+	if !deadline {
+		t.Error("request context has no deadline")
+	}
+
+	if readErr == nil {
+		t.Error("expected error when reading too large body")
+	}
+}
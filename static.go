@@ -0,0 +1,280 @@
+package httpapi
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StaticOptions contains the options of the static file handler.
+type StaticOptions struct {
+	// Index is the file that is served for directories. Defaults to "index.html".
+	Index string
+
+	// Browse enables directory listings for directories without a index file.
+	Browse bool
+
+	// SPA makes the index file in the root be served for paths that don't match
+	// a file, so a single page application can handle the routing.
+	SPA bool
+
+	// Exclude contains path prefixes, e.g "/api", that is responded with
+	// 404 Not Found instead of the SPA index file when no file matches.
+	Exclude []string
+
+	// CacheControl contains Cache-Control header values keyed by file extension,
+	// e.g ".js": "public, max-age=31536000, immutable".
+	CacheControl map[string]string
+
+	// DefaultCacheControl is the Cache-Control header value of files with a
+	// extension that is not in CacheControl. No header is set if it's empty.
+	DefaultCacheControl string
+
+	// Precompressed makes the handler serve "name.br" and "name.gz" files,
+	// when they exist, to requests that accepts brotli or gzip encoding.
+	Precompressed bool
+}
+
+// staticETag is a computed ETag of a file with a given size and modification time.
+type staticETag struct {
+	size    int64
+	modTime time.Time
+	etag    string
+}
+
+// staticHandler serves files from a file system.
+type staticHandler struct {
+	fs    http.FileSystem
+	opts  StaticOptions
+	etags sync.Map
+}
+
+// Static returns a handler that serves files from the file system with ETags,
+// range requests and cache control policies. Files that don't exist are
+// responded with 404 Not Found by the error handle, unless SPA is enabled.
+// Use it with Mount, or with NotFound to serve files for all unmatched paths.
+func Static(fsys http.FileSystem, opts StaticOptions) http.Handler {
+	if opts.Index == "" {
+		opts.Index = "index.html"
+	}
+
+	return &staticHandler{
+		fs:   fsys,
+		opts: opts,
+	}
+}
+
+// ServeHTTP serves the file that matches the request path.
+func (s *staticHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		w.Header().Set("Allow", "GET, HEAD")
+		settingsFromRequest(r).handleError(w, r, NewError(http.StatusMethodNotAllowed, ""))
+		return
+	}
+
+	name := path.Clean("/" + r.URL.Path)
+
+	if s.serveFile(w, r, name) {
+		return
+	}
+
+	if s.opts.SPA && !s.excluded(name) && s.serveFile(w, r, "/"+s.opts.Index) {
+		return
+	}
+
+	settingsFromRequest(r).handleError(w, r, NewError(http.StatusNotFound, ""))
+}
+
+// excluded reports whether the path is excluded from the SPA fallback.
+func (s *staticHandler) excluded(name string) bool {
+	for _, prefix := range s.opts.Exclude {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if name == prefix || strings.HasPrefix(name, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// serveFile serves the file with the given name and reports whether it was served.
+func (s *staticHandler) serveFile(w http.ResponseWriter, r *http.Request, name string) bool {
+	f, err := s.fs.Open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	if fi.IsDir() {
+		index := path.Join(name, s.opts.Index)
+		if s.exists(index) {
+			return s.serveFile(w, r, index)
+		}
+
+		if s.opts.Browse {
+			// Relative links in the listing requires a trailing slash.
+			if !strings.HasSuffix(r.URL.Path, "/") {
+				http.Redirect(w, r, path.Base(r.URL.Path)+"/", http.StatusMovedPermanently)
+				return true
+			}
+
+			s.listDir(w, r, f)
+			return true
+		}
+
+		return false
+	}
+
+	content, size, modTime := io.ReadSeeker(f), fi.Size(), fi.ModTime()
+	served, encoding := name, ""
+
+	if s.opts.Precompressed {
+		addVary(w.Header(), "Accept-Encoding")
+
+		if cf, cfi, enc := s.precompressed(r, name); cf != nil {
+			defer cf.Close()
+
+			content, size, modTime, served, encoding = cf, cfi.Size(), cfi.ModTime(), name+path.Ext(cfi.Name()), enc
+		}
+	}
+
+	// The headers are set when the file can be served, so they don't leak to the SPA fallback.
+	etag, err := s.etag(served, content, size, modTime)
+	if err != nil {
+		return false
+	}
+	w.Header().Set("ETag", etag)
+
+	if encoding != "" {
+		w.Header().Set("Content-Encoding", encoding)
+
+		typ := mime.TypeByExtension(path.Ext(name))
+		if typ == "" {
+			typ = "application/octet-stream"
+		}
+		w.Header().Set("Content-Type", typ)
+	}
+
+	if cc, ok := s.opts.CacheControl[path.Ext(name)]; ok {
+		w.Header().Set("Cache-Control", cc)
+	} else if s.opts.DefaultCacheControl != "" {
+		w.Header().Set("Cache-Control", s.opts.DefaultCacheControl)
+	}
+
+	http.ServeContent(w, r, name, modTime, content)
+
+	return true
+}
+
+// exists reports whether a file that isn't a directory exists.
+func (s *staticHandler) exists(name string) bool {
+	f, err := s.fs.Open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	return err == nil && !fi.IsDir()
+}
+
+// precompressed opens the precompressed variant of the file that best matches the accept encoding header.
+func (s *staticHandler) precompressed(r *http.Request, name string) (http.File, os.FileInfo, string) {
+	exts := map[string]string{"br": ".br", "gzip": ".gz"}
+
+	for _, encoding := range parseAccept(r.Header.Get("Accept-Encoding")) {
+		candidates := []string{encoding}
+		if encoding == "*" {
+			candidates = []string{"br", "gzip"}
+		}
+
+		for _, c := range candidates {
+			ext, ok := exts[c]
+			if !ok {
+				continue
+			}
+
+			f, err := s.fs.Open(name + ext)
+			if err != nil {
+				continue
+			}
+
+			fi, err := f.Stat()
+			if err != nil || fi.IsDir() {
+				f.Close()
+				continue
+			}
+
+			return f, fi, c
+		}
+	}
+
+	return nil, nil, ""
+}
+
+// etag returns the ETag of the file content. ETags are computed from the content
+// and cached until the size or modification time of the file changes.
+func (s *staticHandler) etag(name string, content io.ReadSeeker, size int64, modTime time.Time) (string, error) {
+	if v, ok := s.etags.Load(name); ok {
+		e := v.(*staticETag)
+		if e.size == size && e.modTime.Equal(modTime) {
+			return e.etag, nil
+		}
+	}
+
+	h := sha1.New()
+	if _, err := io.Copy(h, content); err != nil {
+		return "", err
+	}
+
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	etag := `"` + hex.EncodeToString(h.Sum(nil)) + `"`
+	s.etags.Store(name, &staticETag{size: size, modTime: modTime, etag: etag})
+
+	return etag, nil
+}
+
+// listDir writes a HTML directory listing.
+func (s *staticHandler) listDir(w http.ResponseWriter, r *http.Request, f http.File) {
+	files, err := f.Readdir(-1)
+	if err != nil {
+		settingsFromRequest(r).handleError(w, r, NewError(http.StatusInternalServerError, "error reading directory"))
+		return
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name() < files[j].Name()
+	})
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, "<pre>\n")
+	for _, fi := range files {
+		name := fi.Name()
+		if fi.IsDir() {
+			name += "/"
+		}
+
+		u := url.URL{Path: name}
+		fmt.Fprintf(w, "<a href=\"%s\">%s</a>\n", u.String(), html.EscapeString(name))
+	}
+	fmt.Fprint(w, "</pre>\n")
+}
@@ -0,0 +1,14 @@
+//go:build go1.16
+// +build go1.16
+
+package httpapi
+
+import (
+	"io/fs"
+	"net/http"
+)
+
+// StaticFS returns a handler that serves files from a fs.FS, e.g a embed.FS, see Static.
+func StaticFS(fsys fs.FS, opts StaticOptions) http.Handler {
+	return Static(http.FS(fsys), opts)
+}
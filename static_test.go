@@ -0,0 +1,200 @@
+//go:build go1.16
+// +build go1.16
+
+package httpapi
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestStaticFS(t *testing.T) {
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte("console.log('app')"))
+	zw.Close()
+
+	fsys := fstest.MapFS{
+		"index.html":        {Data: []byte("<html>index</html>")},
+		"assets/app.js":     {Data: []byte("console.log('app')")},
+		"assets/app.js.gz":  {Data: gz.Bytes()},
+		"assets/style.css":  {Data: []byte("body{}")},
+		"docs/readme.txt":   {Data: []byte("readme")},
+		"docs/sub/file.txt": {Data: []byte("file")},
+	}
+
+	router := NewRouter()
+	router.Get("/api/users", func() (interface{}, interface{}) {
+		return []string{}, nil
+	})
+	router.NotFound(StaticFS(fsys, StaticOptions{
+		SPA:           true,
+		Exclude:       []string{"/api"},
+		Precompressed: true,
+		CacheControl: map[string]string{
+			".js": "public, max-age=31536000, immutable",
+		},
+		DefaultCacheControl: "no-cache",
+	}))
+
+	tests := []struct {
+		path         string
+		status       int
+		body         string
+		cacheControl string
+	}{
+		{"/", http.StatusOK, "<html>index</html>", "no-cache"},
+		{"/assets/style.css", http.StatusOK, "body{}", "no-cache"},
+		{"/assets/app.js", http.StatusOK, "console.log('app')", "public, max-age=31536000, immutable"},
+		{"/users/1", http.StatusOK, "<html>index</html>", "no-cache"},
+		{"/docs", http.StatusOK, "<html>index</html>", "no-cache"},
+		{"/api/missing", http.StatusNotFound, `{"error":"Not Found"}`, ""},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", test.path, nil)
+		router.ServeHTTP(w, r)
+
+		if w.Code != test.status {
+			t.Errorf("%s: wrong status: want %d, got %d", test.path, test.status, w.Code)
+		}
+
+		if w.Body.String() != test.body {
+			t.Errorf("%s: wrong body: want %q, got %q", test.path, test.body, w.Body.String())
+		}
+
+		if v := w.Header().Get("Cache-Control"); v != test.cacheControl {
+			t.Errorf("%s: wrong cache control: want %q, got %q", test.path, test.cacheControl, v)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/assets/app.js", nil)
+	r.Header.Set("Accept-Encoding", "br;q=0.5, gzip")
+	router.ServeHTTP(w, r)
+
+	if v := w.Header().Get("Content-Encoding"); v != "gzip" {
+		t.Fatalf("wrong content encoding: want gzip, got %q", v)
+	}
+
+	if v := w.Header().Get("Content-Type"); v != "text/javascript; charset=utf-8" && v != "application/javascript" {
+		t.Errorf("wrong content type: got %q", v)
+	}
+
+	zr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if body, _ := ioutil.ReadAll(zr); string(body) != "console.log('app')" {
+		t.Errorf("wrong body: got %q", body)
+	}
+}
+
+// failingFS is a file system where reading precompressed files fails.
+type failingFS struct {
+	http.FileSystem
+}
+
+// failingFile is a file that fails to be read.
+type failingFile struct {
+	http.File
+}
+
+func (fsys failingFS) Open(name string) (http.File, error) {
+	f, err := fsys.FileSystem.Open(name)
+	if err != nil || !strings.HasSuffix(name, ".gz") {
+		return f, err
+	}
+
+	return failingFile{f}, nil
+}
+
+func (f failingFile) Read(p []byte) (int, error) {
+	return 0, errors.New("read failed")
+}
+
+func TestStaticPrecompressedFallback(t *testing.T) {
+	handler := Static(failingFS{http.FS(fstest.MapFS{
+		"index.html":       {Data: []byte("<html>index</html>")},
+		"assets/app.js":    {Data: []byte("console.log('app')")},
+		"assets/app.js.gz": {Data: []byte("gzip")},
+	})}, StaticOptions{SPA: true, Precompressed: true})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/assets/app.js", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	handler.ServeHTTP(w, r)
+
+	if w.Body.String() != "<html>index</html>" {
+		t.Fatalf("wrong body: got %q", w.Body.String())
+	}
+
+	if v := w.Header().Get("Content-Encoding"); v != "" {
+		t.Errorf("fallback has content encoding %q", v)
+	}
+
+	if v := w.Header().Get("Content-Type"); v != "text/html; charset=utf-8" {
+		t.Errorf("wrong content type: got %q", v)
+	}
+}
+
+func TestStaticConditionalAndRange(t *testing.T) {
+	handler := StaticFS(fstest.MapFS{
+		"file.txt":  {Data: []byte("0123456789")},
+		"dir/a.txt": {Data: []byte("a")},
+	}, StaticOptions{Browse: true})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/file.txt", nil)
+	handler.ServeHTTP(w, r)
+
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("missing etag")
+	}
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("GET", "/file.txt", nil)
+	r.Header.Set("If-None-Match", etag)
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusNotModified {
+		t.Errorf("wrong status: want %d, got %d", http.StatusNotModified, w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("GET", "/file.txt", nil)
+	r.Header.Set("Range", "bytes=2-4")
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusPartialContent || w.Body.String() != "234" {
+		t.Errorf("wrong range response: got %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("GET", "/dir/", nil)
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK || !bytes.Contains(w.Body.Bytes(), []byte(`<a href="a.txt">a.txt</a>`)) {
+		t.Errorf("wrong directory listing: got %d %q", w.Code, w.Body.String())
+	}
+
+	handler = StaticFS(fstest.MapFS{"dir/a.txt": {Data: []byte("a")}}, StaticOptions{})
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("GET", "/dir/", nil)
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("directory listed by default: got %d", w.Code)
+	}
+}
@@ -0,0 +1,171 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Timeout returns a route option that sets the timeout of the route.
+func Timeout(d time.Duration) RouteOption {
+	return func(r *Route) {
+		r.Settings.Timeout = d
+	}
+}
+
+// timeoutError returns the error that is responded with when a request times out.
+func (s *Settings) timeoutError() error {
+	if s.TimeoutError != nil {
+		return s.TimeoutError
+	}
+
+	return NewError(http.StatusServiceUnavailable, "request timed out")
+}
+
+// timeout returns a handler that cancels the request context when the timeout in the
+// settings is exceeded. If next hasn't returned by then the timeout error is responded
+// with the response handle of the router, and writes made by next after that are discarded.
+// The response of next is buffered and written when it returns, or when next flushes it.
+// A flushed response is written straight through, so it's not replaced by the timeout error.
+func (r *Router) timeout(next http.Handler) http.Handler {
+	respond := r.ResponseHandle
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		s := settingsFromRequest(req)
+		if s.Timeout <= 0 {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(req.Context(), s.Timeout)
+		defer cancel()
+
+		req = req.WithContext(ctx)
+		tw := &timeoutWriter{w: w, buf: newResponseBuffer()}
+		done := make(chan struct{})
+		panicChan := make(chan interface{}, 1)
+
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicChan <- p
+				}
+			}()
+
+			next.ServeHTTP(tw, req)
+			close(done)
+		}()
+
+		select {
+		case p := <-panicChan:
+			panic(p)
+		case <-done:
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+
+			tw.commit()
+		case <-ctx.Done():
+			tw.mu.Lock()
+			tw.timedOut = true
+			committed := tw.committed
+			tw.mu.Unlock()
+
+			if committed {
+				return
+			}
+
+			err := s.timeoutError()
+			respond(func(*http.Request, Params) (interface{}, interface{}) {
+				return nil, err
+			})(w, req, ParamsFromContext(req.Context()))
+		}
+	})
+}
+
+// timeoutWriter buffers the response of a handler until it returns, flushes or times out.
+// Writes after the timeout returns http.ErrHandlerTimeout.
+type timeoutWriter struct {
+	mu        sync.Mutex
+	w         http.ResponseWriter
+	buf       *responseBuffer
+	timedOut  bool
+	committed bool
+}
+
+// Header returns the header map of the buffered response, or of the
+// response writer when the response has been flushed.
+func (tw *timeoutWriter) Header() http.Header {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.committed {
+		return tw.w.Header()
+	}
+
+	return tw.buf.Header()
+}
+
+// Write writes the data to the buffer, or to the response writer when the
+// response has been flushed, if the handler hasn't timed out.
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+
+	if tw.committed {
+		return tw.w.Write(p)
+	}
+
+	return tw.buf.Write(p)
+}
+
+// WriteHeader sets the status code if the handler hasn't timed out.
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if !tw.timedOut && !tw.committed {
+		tw.buf.WriteHeader(status)
+	}
+}
+
+// Flush writes the buffered response to the response writer and flushes it if the
+// handler hasn't timed out. Later writes are written straight to the response writer.
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return
+	}
+
+	tw.commit()
+
+	if f, ok := tw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// commit writes the buffered response to the response writer once. The lock must be held.
+func (tw *timeoutWriter) commit() {
+	if tw.committed {
+		return
+	}
+
+	tw.committed = true
+
+	for k, v := range tw.buf.header {
+		tw.w.Header()[k] = v
+	}
+
+	if tw.buf.status != 0 {
+		tw.w.WriteHeader(tw.buf.status)
+	}
+
+	tw.w.Write(tw.buf.body.Bytes())
+	tw.buf.body.Reset()
+}
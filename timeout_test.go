@@ -0,0 +1,119 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	late := make(chan error, 1)
+
+	router := NewRouter()
+	router.Settings.Timeout = 10 * time.Millisecond
+	router.Get("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		time.Sleep(10 * time.Millisecond)
+		_, err := w.Write([]byte("late"))
+		late <- err
+	})
+	router.Get("/fast", func() (interface{}, interface{}) {
+		return "fast", nil
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/slow", nil)
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("wrong status: want %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+
+	if want := `{"error":"request timed out"}`; w.Body.String() != want {
+		t.Errorf("wrong body: want %s, got %s", want, w.Body.String())
+	}
+
+	if err := <-late; err != http.ErrHandlerTimeout {
+		t.Errorf("late write was not discarded: got %v", err)
+	}
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("GET", "/fast", nil)
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK || w.Body.String() != `"fast"` {
+		t.Errorf("wrong response: got %d %s", w.Code, w.Body.String())
+	}
+
+	group := router.Group("/api")
+	group.Settings.TimeoutError = NewError(http.StatusGatewayTimeout, "upstream timed out")
+	group.Get("/gateway", func(r *http.Request) (interface{}, interface{}) {
+		<-r.Context().Done()
+		return nil, nil
+	}, Timeout(5*time.Millisecond))
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("GET", "/api/gateway", nil)
+	router.ServeHTTP(w, r)
+
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("wrong status: want %d, got %d", http.StatusGatewayTimeout, w.Code)
+	}
+}
+
+func TestTimeoutFlush(t *testing.T) {
+	flushed := make(chan struct{})
+	checked := make(chan struct{})
+	late := make(chan error, 1)
+
+	router := NewRouter()
+	router.Settings.Timeout = 10 * time.Millisecond
+	router.Get("/events", func(w http.ResponseWriter, r *http.Request) {
+		f, ok := w.(http.Flusher)
+		if !ok {
+			close(flushed)
+			late <- nil
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: 1\n\n"))
+		f.Flush()
+		close(flushed)
+		<-checked
+
+		<-r.Context().Done()
+		time.Sleep(10 * time.Millisecond)
+		_, err := w.Write([]byte("data: 2\n\n"))
+		late <- err
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/events", nil)
+
+	done := make(chan struct{})
+	go func() {
+		router.ServeHTTP(w, r)
+		close(done)
+	}()
+
+	<-flushed
+	if !w.Flushed || w.Body.String() != "data: 1\n\n" {
+		t.Errorf("response was not flushed: got %q", w.Body.String())
+	}
+	close(checked)
+	<-done
+
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/event-stream" {
+		t.Errorf("flushed response was replaced: got %d %v", w.Code, w.Header())
+	}
+
+	if w.Body.String() != "data: 1\n\n" {
+		t.Errorf("wrong body: got %q", w.Body.String())
+	}
+
+	if err := <-late; err != http.ErrHandlerTimeout {
+		t.Errorf("late write was not discarded: got %v", err)
+	}
+}
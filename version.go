@@ -0,0 +1,190 @@
+package httpapi
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VersionSelector selects the API version that a request asks for.
+type VersionSelector struct {
+	// Select returns the version that the request asks for,
+	// or a empty string if the request don't ask for a version.
+	Select func(r *http.Request) string
+
+	// Headers contains the request headers that Select reads. They are added to
+	// the Vary header of the responses, so shared caches don't respond with the
+	// wrong version.
+	Headers []string
+}
+
+// HeaderVersion returns a version selector that selects the version from the given header, e.g "API-Version".
+func HeaderVersion(header string) VersionSelector {
+	return VersionSelector{
+		Select: func(r *http.Request) string {
+			return r.Header.Get(header)
+		},
+		Headers: []string{header},
+	}
+}
+
+// QueryVersion returns a version selector that selects the version from the given query parameter.
+func QueryVersion(key string) VersionSelector {
+	return VersionSelector{
+		Select: func(r *http.Request) string {
+			return r.URL.Query().Get(key)
+		},
+	}
+}
+
+// AcceptVersion returns a version selector that selects the version from a vendor
+// media type in the Accept header, e.g "application/vnd.{vendor}.v2+json".
+func AcceptVersion(vendor string) VersionSelector {
+	re := regexp.MustCompile(`application/vnd\.` + regexp.QuoteMeta(vendor) + `\.([^+;,\s]+)`)
+
+	return VersionSelector{
+		Select: func(r *http.Request) string {
+			if m := re.FindStringSubmatch(r.Header.Get("Accept")); m != nil {
+				return m[1]
+			}
+
+			return ""
+		},
+		Headers: []string{"Accept"},
+	}
+}
+
+// Deprecation contains the deprecation information of a API version.
+type Deprecation struct {
+	// Date is when the version was deprecated.
+	// If it's zero the version is deprecated without a date.
+	Date time.Time
+
+	// Sunset is when the version will stop responding. Optional.
+	Sunset time.Time
+
+	// Link is a link to documentation about the deprecation. Optional.
+	Link string
+}
+
+// Versions is used to register handles for multiple API versions.
+type Versions struct {
+	router       *Router
+	selectors    []VersionSelector
+	vary         []string
+	mu           sync.RWMutex
+	deprecations map[string]*Deprecation
+
+	// Default is the version that is used when a request don't ask for a version.
+	Default string
+}
+
+// Versions returns new *Versions that registers handles on the router.
+// The given selectors selects the version of a request. Without
+// any selectors the versions will be path based, e.g "/v1/users".
+// Responses has a Vary header with the headers of the selectors, also when no
+// version matches the request, so shared caches don't respond with the wrong version.
+func (r *Router) Versions(selectors ...VersionSelector) *Versions {
+	var vary []string
+	seen := make(map[string]bool)
+	for _, s := range selectors {
+		for _, h := range s.Headers {
+			h = http.CanonicalHeaderKey(h)
+			if !seen[h] {
+				seen[h] = true
+				vary = append(vary, h)
+			}
+		}
+	}
+
+	return &Versions{
+		router:       r,
+		selectors:    selectors,
+		vary:         vary,
+		deprecations: make(map[string]*Deprecation),
+	}
+}
+
+// Version returns new *Router for the given version. Handles registered on it will only
+// be used for requests asking for the version. Path based versions is a group with
+// the version as path, so the same path can be registered for multiple versions.
+func (v *Versions) Version(name string) *Router {
+	var r *Router
+
+	if len(v.selectors) == 0 {
+		r = v.router.Group("/" + name)
+	} else {
+		r = v.router.Group("/")
+		r.matchers = append(append([]matcher(nil), r.matchers...), func(req *http.Request) bool {
+			return v.match(name, req)
+		})
+		r.vary = append(append([]string(nil), r.vary...), v.vary...)
+	}
+
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			v.writeHeaders(w, name)
+			next.ServeHTTP(w, req)
+		})
+	})
+
+	return r
+}
+
+// Deprecate marks the version as deprecated. Responses from the version will have
+// Deprecation, Sunset and Link headers.
+func (v *Versions) Deprecate(name string, d Deprecation) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.deprecations[normalizeVersion(name)] = &d
+}
+
+// Selected returns the version that the request asks for, or the default version if none.
+func (v *Versions) Selected(r *http.Request) string {
+	for _, s := range v.selectors {
+		if version := s.Select(r); version != "" {
+			return version
+		}
+	}
+
+	return v.Default
+}
+
+// match reports whether the request asks for the given version.
+func (v *Versions) match(name string, r *http.Request) bool {
+	return normalizeVersion(v.Selected(r)) == normalizeVersion(name)
+}
+
+// writeHeaders writes the deprecation headers of the version if it's deprecated.
+func (v *Versions) writeHeaders(w http.ResponseWriter, name string) {
+	v.mu.RLock()
+	d := v.deprecations[normalizeVersion(name)]
+	v.mu.RUnlock()
+
+	if d == nil {
+		return
+	}
+
+	if d.Date.IsZero() {
+		w.Header().Set("Deprecation", "true")
+	} else {
+		w.Header().Set("Deprecation", fmt.Sprintf("@%d", d.Date.Unix()))
+	}
+
+	if !d.Sunset.IsZero() {
+		w.Header().Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+	}
+
+	if d.Link != "" {
+		w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="deprecation"`, d.Link))
+	}
+}
+
+// normalizeVersion normalizes a version name so "v1", "V1" and "1" is the same version.
+func normalizeVersion(name string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(name)), "v")
+}
@@ -0,0 +1,149 @@
+package httpapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVersionsSelectors(t *testing.T) {
+	router := NewRouter()
+	versions := router.Versions(HeaderVersion("API-Version"), AcceptVersion("x"), QueryVersion("version"))
+	versions.Default = "2"
+
+	versions.Version("1").Get("/users", func() (interface{}, interface{}) {
+		return "v1", nil
+	})
+	versions.Version("v2").Get("/users", func() (interface{}, interface{}) {
+		return "v2", nil
+	})
+
+	tests := []struct {
+		url    string
+		header string
+		value  string
+		body   string
+		status int
+	}{
+		{"/users", "", "", `"v2"`, http.StatusOK},
+		{"/users", "API-Version", "1", `"v1"`, http.StatusOK},
+		{"/users", "Accept", "application/vnd.x.v1+json", `"v1"`, http.StatusOK},
+		{"/users?version=v1", "", "", `"v1"`, http.StatusOK},
+		{"/users?version=3", "", "", "", http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", test.url, nil)
+		if test.header != "" {
+			r.Header.Set(test.header, test.value)
+		}
+		router.ServeHTTP(w, r)
+
+		if w.Code != test.status {
+			t.Errorf("wrong status for %s %s: want %d, got %d", test.url, test.value, test.status, w.Code)
+		}
+
+		if test.body != "" && w.Body.String() != test.body {
+			t.Errorf("wrong body for %s %s: want %s, got %s", test.url, test.value, test.body, w.Body.String())
+		}
+
+		if vary := w.Header()["Vary"]; len(vary) != 2 || vary[0] != "Api-Version" || vary[1] != "Accept" {
+			t.Errorf("wrong vary for %s %s: got %v", test.url, test.value, vary)
+		}
+	}
+}
+
+func TestVersionsCustomSelector(t *testing.T) {
+	router := NewRouter()
+	versions := router.Versions(VersionSelector{
+		Select: func(r *http.Request) string {
+			return strings.TrimPrefix(r.Header.Get("User-Agent"), "app/")
+		},
+		Headers: []string{"user-agent"},
+	})
+
+	versions.Version("1").Get("/users", func() (interface{}, interface{}) {
+		return "v1", nil
+	})
+
+	for _, agent := range []string{"app/1", "app/2"} {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/users", nil)
+		r.Header.Set("User-Agent", agent)
+		router.ServeHTTP(w, r)
+
+		if v := w.Header().Get("Vary"); v != "User-Agent" {
+			t.Errorf("wrong vary for %s: got %s", agent, v)
+		}
+	}
+}
+
+func TestVersionsPath(t *testing.T) {
+	router := NewRouter()
+	versions := router.Versions()
+
+	versions.Version("v1").Get("/users", func() (interface{}, interface{}) {
+		return "v1", nil
+	})
+	versions.Version("v2").Get("/users", func() (interface{}, interface{}) {
+		return "v2", nil
+	})
+
+	for _, v := range []string{"v1", "v2"} {
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest("GET", "/"+v+"/users", nil)
+		router.ServeHTTP(w, r)
+
+		if w.Body.String() != `"`+v+`"` {
+			t.Errorf("wrong body for %s: got %s", v, w.Body.String())
+		}
+	}
+}
+
+func TestVersionsDeprecate(t *testing.T) {
+	router := NewRouter()
+	versions := router.Versions(HeaderVersion("API-Version"))
+	versions.Default = "2"
+
+	versions.Version("1").Get("/users", func() (interface{}, interface{}) {
+		return nil, nil
+	})
+	versions.Version("2").Get("/users", func() (interface{}, interface{}) {
+		return nil, nil
+	})
+
+	sunset := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	versions.Deprecate("1", Deprecation{
+		Date:   time.Unix(1700000000, 0),
+		Sunset: sunset,
+		Link:   "https://example.com/deprecation",
+	})
+
+	w := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/users", nil)
+	r.Header.Set("API-Version", "1")
+	router.ServeHTTP(w, r)
+
+	if v := w.Header().Get("Deprecation"); v != "@1700000000" {
+		t.Errorf("wrong deprecation header: got %s", v)
+	}
+
+	if v := w.Header().Get("Sunset"); v != sunset.Format(http.TimeFormat) {
+		t.Errorf("wrong sunset header: got %s", v)
+	}
+
+	if v := w.Header().Get("Link"); v != `<https://example.com/deprecation>; rel="deprecation"` {
+		t.Errorf("wrong link header: got %s", v)
+	}
+
+	w = httptest.NewRecorder()
+	r, _ = http.NewRequest("GET", "/users", nil)
+	router.ServeHTTP(w, r)
+
+	if v := w.Header().Get("Deprecation"); v != "" {
+		t.Errorf("unexpected deprecation header: got %s", v)
+	}
+}